@@ -0,0 +1,301 @@
+package api
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/vultisig/pluginagent/policy"
+	"github.com/vultisig/pluginagent/proposal"
+	"github.com/vultisig/pluginagent/storage/interfaces"
+	"github.com/vultisig/pluginagent/types"
+	vtypes "github.com/vultisig/verifier/types"
+	vgcommon "github.com/vultisig/vultisig-go/common"
+)
+
+const (
+	defaultApprovalExpiry  = 24 * time.Hour
+	approvalExpiryInterval = 30 * time.Second
+
+	approvalActionApprove = "approve"
+	approvalActionReject  = "reject"
+
+	errApprovalExpired  = "approval expired"
+	errApprovalRejected = "rejected by vault owner"
+)
+
+// ApprovalThreshold is a configured threshold a proposal exceeds.
+type ApprovalThreshold struct {
+	Token     string `json:"token"`
+	Amount    string `json:"amount"`
+	Threshold string `json:"threshold"`
+}
+
+// ApprovalEventData is the event data of the proposal approval events. Pending approval events
+// carry the messages the vault owner signs to approve or reject the proposal.
+type ApprovalEventData struct {
+	ProposalID     string              `json:"proposal_id"`
+	Network        string              `json:"network"`
+	TxHexes        []string            `json:"tx_hexes"`
+	Thresholds     []ApprovalThreshold `json:"thresholds,omitempty"`
+	ExpiresAt      *time.Time          `json:"expires_at,omitempty"`
+	ApproveMessage string              `json:"approve_message,omitempty"`
+	RejectMessage  string              `json:"reject_message,omitempty"`
+}
+
+// ApprovalRequest is the body of the approve and reject endpoints. Signature is the hex encoded
+// signature of the approval message by the vault, verified like policy signatures.
+type ApprovalRequest struct {
+	Signature string `json:"signature" validate:"required"`
+}
+
+// approvalMessage is the message the vault owner signs to approve or reject a proposal. It binds
+// the action to the proposal and its transactions, delimited like the policy signature message.
+func approvalMessage(action string, p types.Proposal) string {
+	return strings.Join([]string{action, p.ID.String(), p.PolicyID.String(), strings.Join(p.TxHexes(), ",")}, "*#*")
+}
+
+// approvalThresholds returns the configured thresholds the transactions exceed. The values of a
+// batch add up.
+func (s *Server) approvalThresholds(chain vgcommon.Chain, txs []proposal.Tx) ([]ApprovalThreshold, error) {
+	var chainThresholds map[string]string
+	for name, thresholds := range s.approvalCfg.Thresholds {
+		if c, err := vgcommon.FromString(name); err == nil && c == chain {
+			chainThresholds = thresholds
+			break
+		}
+	}
+	if len(chainThresholds) == 0 {
+		return nil, nil
+	}
+
+	values := make(map[string]*big.Int)
+	for _, tx := range txs {
+		txValues, err := policy.TransactionValues(chain, tx)
+		if err != nil {
+			return nil, err
+		}
+		for token, amount := range txValues {
+			if values[token] == nil {
+				values[token] = new(big.Int)
+			}
+			values[token].Add(values[token], amount)
+		}
+	}
+
+	var exceeded []ApprovalThreshold
+	for token, amount := range values {
+		thresholdStr, ok := chainThresholds[strings.ToLower(token)]
+		if !ok {
+			continue
+		}
+		threshold, ok := new(big.Int).SetString(thresholdStr, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid approval threshold %q of %s", thresholdStr, token)
+		}
+		if amount.Cmp(threshold) > 0 {
+			exceeded = append(exceeded, ApprovalThreshold{
+				Token:     token,
+				Amount:    amount.String(),
+				Threshold: threshold.String(),
+			})
+		}
+	}
+	sort.Slice(exceeded, func(i, j int) bool {
+		return exceeded[i].Token < exceeded[j].Token
+	})
+	return exceeded, nil
+}
+
+func (s *Server) approvalExpiry() time.Duration {
+	if s.approvalCfg.Expiry > 0 {
+		return s.approvalCfg.Expiry
+	}
+	return defaultApprovalExpiry
+}
+
+// insertApprovalEvent announces a change of the approval state of a proposal on the system events.
+func (s *Server) insertApprovalEvent(
+	ctx context.Context,
+	eventType types.SystemEventType,
+	p types.Proposal,
+	thresholds []ApprovalThreshold,
+) error {
+	data := ApprovalEventData{
+		ProposalID: p.ID.String(),
+		Network:    p.Chain.String(),
+		TxHexes:    p.TxHexes(),
+		Thresholds: thresholds,
+	}
+	if eventType == types.SystemEventTypeProposalPendingApproval {
+		data.ExpiresAt = p.ApprovalExpiresAt
+		data.ApproveMessage = approvalMessage(approvalActionApprove, p)
+		data.RejectMessage = approvalMessage(approvalActionReject, p)
+	}
+	eventData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event data: %w", err)
+	}
+
+	_, err = s.db.InsertEvent(ctx, &types.SystemEvent{
+		PublicKey: &p.PublicKey,
+		PolicyID:  &p.PolicyID,
+		EventType: eventType,
+		EventData: eventData,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to insert %s event: %w", eventType, err)
+	}
+	return nil
+}
+
+// ApproveProposal queues a proposal pending approval for signing once the vault owner signed the
+// approve message of the proposal.
+func (s *Server) ApproveProposal(c echo.Context) error {
+	return s.resolveApproval(c, approvalActionApprove)
+}
+
+// RejectProposal fails a proposal pending approval once the vault owner signed the reject
+// message of the proposal.
+func (s *Server) RejectProposal(c echo.Context) error {
+	return s.resolveApproval(c, approvalActionReject)
+}
+
+func (s *Server) resolveApproval(c echo.Context, action string) error {
+	proposalID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInvalidRequest, "invalid proposal ID"))
+	}
+	var req ApprovalRequest
+	if err := c.Bind(&req); err != nil {
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInvalidRequest, "failed to parse request body"))
+	}
+	if err := c.Validate(&req); err != nil {
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInvalidRequest, validationMessage(err)))
+	}
+
+	ctx := c.Request().Context()
+	p, err := s.db.GetProposal(ctx, proposalID)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrProposalNotFound) {
+			return codedError(c, NewCodedErrorResponse(ErrorCodeProposalNotFound, "proposal not found"))
+		}
+		s.logger.WithError(err).WithField("proposal_id", proposalID).Error("Failed to get proposal")
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInternal, "failed to get proposal"))
+	}
+	if p.Status != types.ProposalStatusPendingApproval {
+		return codedError(c, NewCodedErrorResponse(ErrorCodeProposalNotPending, fmt.Sprintf("proposal is %s", p.Status)))
+	}
+
+	pluginPolicy, err := s.policyService.GetPluginPolicy(ctx, p.PolicyID)
+	if err != nil {
+		s.logger.WithError(err).WithField("policy_id", p.PolicyID).Error("Failed to get plugin policy")
+		return codedError(c, policyErrorResponse(err))
+	}
+	msg := approvalMessage(action, *p)
+	if !s.verifyVaultSignature(pluginPolicy.PublicKey, pluginPolicy.PluginID.String(), []byte(msg), req.Signature) {
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInvalidSignature, "invalid approval signature"))
+	}
+
+	// The policy may have changed or been deactivated while the proposal waited for approval
+	if action == approvalActionApprove {
+		if err := s.revalidateProposal(*pluginPolicy, *p); err != nil {
+			s.logger.WithError(err).WithField("proposal_id", proposalID).Error("Approved proposal is no longer allowed by policy")
+			errMsg := err.Error()
+			_, er := s.db.ResolvePendingApproval(ctx, proposalID, types.ProposalStatusFailed, &errMsg, time.Now().UTC())
+			if er != nil && !errors.Is(er, interfaces.ErrProposalNotPending) {
+				s.logger.WithError(er).WithField("proposal_id", proposalID).Error("Failed to mark proposal as failed")
+			}
+			return codedError(c, policyErrorResponse(err))
+		}
+	}
+
+	status := types.ProposalStatusQueued
+	eventType := types.SystemEventTypeProposalApproved
+	var errMsg *string
+	if action == approvalActionReject {
+		status = types.ProposalStatusFailed
+		eventType = types.SystemEventTypeProposalRejected
+		rejected := errApprovalRejected
+		errMsg = &rejected
+	}
+
+	resolved, err := s.db.ResolvePendingApproval(ctx, proposalID, status, errMsg, time.Now().UTC())
+	if err != nil {
+		if !errors.Is(err, interfaces.ErrProposalNotPending) {
+			s.logger.WithError(err).WithField("proposal_id", proposalID).Error("Failed to resolve approval")
+			return codedError(c, NewCodedErrorResponse(ErrorCodeInternal, "failed to resolve approval"))
+		}
+		// Resolved concurrently, or expired and not swept yet
+		if p.ApprovalExpiresAt != nil && !p.ApprovalExpiresAt.After(time.Now().UTC()) {
+			return codedError(c, NewCodedErrorResponse(ErrorCodeApprovalExpired, errApprovalExpired))
+		}
+		return codedError(c, NewCodedErrorResponse(ErrorCodeProposalNotPending, "proposal is no longer pending approval"))
+	}
+
+	if err := s.insertApprovalEvent(ctx, eventType, *resolved, nil); err != nil {
+		s.logger.WithError(err).WithField("proposal_id", proposalID).Error("Failed to record approval event")
+	}
+	s.logger.WithField("proposal_id", proposalID).WithField("action", action).Info("Proposal approval resolved")
+
+	if action == approvalActionReject {
+		return c.JSON(http.StatusOK, s.toProposalResponse(*resolved))
+	}
+	if resp := s.enqueueProposal(ctx, *resolved); resp != nil {
+		return codedError(c, *resp)
+	}
+	return c.JSON(http.StatusAccepted, s.toProposalResponse(*resolved))
+}
+
+// revalidateProposal checks every transaction of the proposal against the current policy.
+func (s *Server) revalidateProposal(pluginPolicy vtypes.PluginPolicy, p types.Proposal) error {
+	txHexes := p.TxHexes()
+	for i, txHex := range txHexes {
+		payload, err := hex.DecodeString(txHex)
+		if err != nil {
+			return fmt.Errorf("failed to decode tx hex of transaction %d: %w", i, err)
+		}
+		tx, err := proposal.DecodeTx(p.Chain, payload)
+		if err != nil {
+			return fmt.Errorf("failed to decode transaction %d: %w", i, err)
+		}
+		if _, err := s.policyService.ValidateTransaction(pluginPolicy, p.Chain, tx.PolicyPayload()); err != nil {
+			if len(txHexes) > 1 {
+				return fmt.Errorf("transaction %d: %w", i, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// expireApprovals periodically fails proposals whose approval expired, which releases their
+// spend and schedule reservations.
+func (s *Server) expireApprovals() {
+	ticker := time.NewTicker(approvalExpiryInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx := context.Background()
+		expired, err := s.db.ExpirePendingApprovals(ctx, time.Now().UTC(), errApprovalExpired)
+		if err != nil {
+			s.logger.WithError(err).Error("Failed to expire pending approvals")
+			continue
+		}
+		for _, p := range expired {
+			s.logger.WithField("proposal_id", p.ID).Info("Proposal approval expired")
+			if err := s.insertApprovalEvent(ctx, types.SystemEventTypeProposalApprovalExpired, p, nil); err != nil {
+				s.logger.WithError(err).WithField("proposal_id", p.ID).Error("Failed to record approval event")
+			}
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/vultisig/pluginagent/policy"
+	"github.com/vultisig/pluginagent/proposal"
+	vgcommon "github.com/vultisig/vultisig-go/common"
+)
+
+// DecodeRequest is the body of POST /propose/decode. With a policy_id, the resources are matched
+// against the rules of the policy as well.
+type DecodeRequest struct {
+	Network  string `json:"network" validate:"required"`
+	TxHex    string `json:"tx_hex" validate:"required,hexadecimal"`
+	PolicyID string `json:"policy_id,omitempty" validate:"omitempty,uuid"`
+}
+
+type DecodeResponse struct {
+	Network  string          `json:"network"`
+	TxType   proposal.TxType `json:"tx_type"`
+	To       string          `json:"to,omitempty"`
+	Value    string          `json:"value"`
+	Nonce    uint64          `json:"nonce"`
+	GasLimit uint64          `json:"gas_limit"`
+	Selector string          `json:"selector,omitempty"`
+	// Resources are the recipe resources the transaction can be a call of, resources of the recipe
+	// specification first
+	Resources []DecodedResource `json:"resources"`
+}
+
+type DecodedResource struct {
+	ResourcePath string `json:"resource_path"`
+	Signature    string `json:"signature,omitempty"`
+	// Supported is set for resources listed in the recipe specification of the plugin
+	Supported bool `json:"supported"`
+	// RuleIDs are the rules of the policy with the resource that target the transaction recipient,
+	// identified like in rule violations: by ID, or by position (#<index>) for rules without one
+	RuleIDs    []string                  `json:"rule_ids,omitempty"`
+	Parameters []policy.DecodedParameter `json:"parameters"`
+}
+
+// DecodeProposal decodes an EVM transaction the way Propose understands it and maps it to recipe
+// resource paths with named ABI decoded parameters. Nothing is recorded or signed.
+func (s *Server) DecodeProposal(c echo.Context) error {
+	var req DecodeRequest
+	if err := c.Bind(&req); err != nil {
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInvalidRequest, "failed to parse request body"))
+	}
+	if err := c.Validate(&req); err != nil {
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInvalidRequest, validationMessage(err)))
+	}
+
+	chain, err := vgcommon.FromString(req.Network)
+	if err != nil || !chain.IsEvm() {
+		return codedError(c, NewCodedErrorResponse(ErrorCodeUnsupportedChain, fmt.Sprintf("only EVM networks can be decoded, got %q", req.Network)))
+	}
+	payload, err := hex.DecodeString(strings.TrimPrefix(req.TxHex, "0x"))
+	if err != nil {
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInvalidRequest, fmt.Sprintf("failed to decode tx_hex: %v", err)))
+	}
+	evmTx, err := proposal.DecodeEvmTx(chain, payload)
+	if err != nil {
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInvalidTransaction, fmt.Sprintf("failed to decode transaction: %v", err)))
+	}
+
+	calls, err := policy.MatchEvmResources(chain, evmTx.Tx)
+	if err != nil {
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInvalidTransaction, err.Error()))
+	}
+
+	// Rules are matched by resource path and target
+	ruleIDs := make(map[string][]string)
+	if req.PolicyID != "" {
+		// Validated by the uuid tag
+		policyID, _ := uuid.Parse(req.PolicyID)
+		pluginPolicy, er := s.policyService.GetPluginPolicy(c.Request().Context(), policyID)
+		if er != nil {
+			s.logger.WithError(er).WithField("policy_id", policyID).Error("Failed to get plugin policy")
+			return codedError(c, policyErrorResponse(er))
+		}
+		recipe, er := pluginPolicy.GetRecipe()
+		if er != nil {
+			return codedError(c, NewCodedErrorResponse(ErrorCodeInternal, fmt.Sprintf("failed to get recipe: %v", er)))
+		}
+		for i, rule := range recipe.GetRules() {
+			ruleID := policy.RuleKey(rule, i)
+			ok, e := policy.RuleTargetMatches(rule, strings.ToLower(chain.String()), evmTx.Tx.To())
+			if e != nil {
+				s.logger.WithError(e).WithField("rule_id", ruleID).Warn("Failed to match rule target")
+				continue
+			}
+			if ok {
+				ruleIDs[rule.GetResource()] = append(ruleIDs[rule.GetResource()], ruleID)
+			}
+		}
+	}
+
+	supported := s.supportedResources()
+	resources := make([]DecodedResource, 0, len(calls))
+	for _, call := range calls {
+		resources = append(resources, DecodedResource{
+			ResourcePath: call.Resource,
+			Signature:    call.Signature,
+			Supported:    supported[call.Resource],
+			RuleIDs:      ruleIDs[call.Resource],
+			Parameters:   call.Parameters,
+		})
+	}
+	sort.SliceStable(resources, func(i, j int) bool {
+		return resources[i].Supported && !resources[j].Supported
+	})
+
+	resp := DecodeResponse{
+		Network:   chain.String(),
+		TxType:    evmTx.Type(),
+		Value:     evmTx.Tx.Value().String(),
+		Nonce:     evmTx.Tx.Nonce(),
+		GasLimit:  evmTx.Tx.Gas(),
+		Resources: resources,
+	}
+	if evmTx.Tx.To() != nil {
+		resp.To = evmTx.Tx.To().Hex()
+	}
+	if data := evmTx.Tx.Data(); len(data) >= 4 {
+		resp.Selector = hexutil.Encode(data[:4])
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// supportedResources returns the resource paths of the recipe specification. A specification
+// that can't be read supports no resources.
+func (s *Server) supportedResources() map[string]bool {
+	supported := make(map[string]bool)
+	schema, err := policy.LoadRecipeSchema(s.pluginCfg.RecipeSpecificationFilePath)
+	if err != nil {
+		s.logger.WithError(err).Warn("Failed to load recipe specification")
+		return supported
+	}
+	for _, resource := range schema.GetSupportedResources() {
+		supported[resource.GetResourcePath().GetFull()] = true
+	}
+	return supported
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/vultisig/pluginagent/policy"
+	"github.com/vultisig/pluginagent/proposal"
+	"github.com/vultisig/pluginagent/storage/interfaces"
+)
+
+// ErrorCode is a stable, machine readable identifier of an API failure. Clients should branch on
+// the code, the message is meant for humans and may change.
+type ErrorCode string
+
+const (
+	ErrorCodeInvalidRequest      ErrorCode = "invalid_request"
+	ErrorCodeInvalidTransaction  ErrorCode = "invalid_transaction"
+	ErrorCodeInvalidPolicy       ErrorCode = "invalid_policy"
+	ErrorCodeUnsupportedChain    ErrorCode = "unsupported_chain"
+	ErrorCodePolicyNotFound      ErrorCode = "policy_not_found"
+	ErrorCodePolicyInactive      ErrorCode = "policy_inactive"
+	ErrorCodeVersionConflict     ErrorCode = "policy_version_conflict"
+	ErrorCodeRestoreExpired      ErrorCode = "restore_window_expired"
+	ErrorCodeRevisionNotFound    ErrorCode = "revision_not_found"
+	ErrorCodeChainMismatch       ErrorCode = "chain_mismatch"
+	ErrorCodeRuleViolation       ErrorCode = "rule_violation"
+	ErrorCodeSpendLimitExceeded  ErrorCode = "spend_limit_exceeded"
+	ErrorCodeFeeCeilingExceeded  ErrorCode = "fee_ceiling_exceeded"
+	ErrorCodeRateLimited         ErrorCode = "rate_limited"
+	ErrorCodeTransactionReverted ErrorCode = "transaction_reverted"
+	ErrorCodeGasLimitTooLow      ErrorCode = "gas_limit_too_low"
+	ErrorCodeSimulationFailed    ErrorCode = "simulation_failed"
+	ErrorCodeProposalNotFound    ErrorCode = "proposal_not_found"
+	ErrorCodeProposalNotPending  ErrorCode = "proposal_not_pending"
+	ErrorCodeApprovalExpired     ErrorCode = "approval_expired"
+	ErrorCodeNonceConflict       ErrorCode = "nonce_conflict"
+	ErrorCodeReservationNotFound ErrorCode = "reservation_not_found"
+	ErrorCodeInvalidSignature    ErrorCode = "invalid_signature"
+	ErrorCodeUnauthorized        ErrorCode = "unauthorized"
+	ErrorCodeSignerUnavailable   ErrorCode = "signer_unavailable"
+	ErrorCodeIdempotencyMismatch ErrorCode = "idempotency_key_reused"
+	ErrorCodeInternal            ErrorCode = "internal_error"
+)
+
+var errorCodeStatus = map[ErrorCode]int{
+	ErrorCodeInvalidRequest:      http.StatusBadRequest,
+	ErrorCodeInvalidTransaction:  http.StatusBadRequest,
+	ErrorCodeInvalidPolicy:       http.StatusUnprocessableEntity,
+	ErrorCodeUnsupportedChain:    http.StatusUnprocessableEntity,
+	ErrorCodePolicyNotFound:      http.StatusNotFound,
+	ErrorCodePolicyInactive:      http.StatusConflict,
+	ErrorCodeVersionConflict:     http.StatusConflict,
+	ErrorCodeRestoreExpired:      http.StatusGone,
+	ErrorCodeRevisionNotFound:    http.StatusNotFound,
+	ErrorCodeChainMismatch:       http.StatusUnprocessableEntity,
+	ErrorCodeRuleViolation:       http.StatusForbidden,
+	ErrorCodeSpendLimitExceeded:  http.StatusForbidden,
+	ErrorCodeFeeCeilingExceeded:  http.StatusForbidden,
+	ErrorCodeRateLimited:         http.StatusTooManyRequests,
+	ErrorCodeTransactionReverted: http.StatusUnprocessableEntity,
+	ErrorCodeGasLimitTooLow:      http.StatusUnprocessableEntity,
+	ErrorCodeSimulationFailed:    http.StatusBadGateway,
+	ErrorCodeProposalNotFound:    http.StatusNotFound,
+	ErrorCodeProposalNotPending:  http.StatusConflict,
+	ErrorCodeApprovalExpired:     http.StatusConflict,
+	ErrorCodeNonceConflict:       http.StatusConflict,
+	ErrorCodeReservationNotFound: http.StatusNotFound,
+	ErrorCodeInvalidSignature:    http.StatusUnauthorized,
+	ErrorCodeUnauthorized:        http.StatusUnauthorized,
+	ErrorCodeIdempotencyMismatch: http.StatusUnprocessableEntity,
+	ErrorCodeSignerUnavailable:   http.StatusServiceUnavailable,
+	ErrorCodeInternal:            http.StatusInternalServerError,
+}
+
+// HTTPStatus returns the status code the error code is served with.
+func (c ErrorCode) HTTPStatus() int {
+	if status, ok := errorCodeStatus[c]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+func NewCodedErrorResponse(code ErrorCode, message string) ErrorResponse {
+	return ErrorResponse{
+		Code:    code,
+		Message: message,
+	}
+}
+
+type ScheduleErrorDetails struct {
+	MaxTxsPerWindow int       `json:"max_txs_per_window"`
+	WindowSeconds   int64     `json:"window_seconds"`
+	Executed        int       `json:"executed"`
+	NextAllowedAt   time.Time `json:"next_allowed_at"`
+}
+
+// FeeCeilingDetails lists the fee fields above their ceiling. ProposalID is the failed proposal
+// the violation is recorded on.
+type FeeCeilingDetails struct {
+	ProposalID *uuid.UUID              `json:"proposal_id,omitempty"`
+	Violations []proposal.FeeViolation `json:"violations"`
+}
+
+// VersionConflictDetails tells a client whose update lost a race which version to rebase on.
+type VersionConflictDetails struct {
+	CurrentVersion   int `json:"current_version"`
+	SubmittedVersion int `json:"submitted_version"`
+}
+
+// codedError writes the error response with the status of its code.
+func codedError(c echo.Context, resp ErrorResponse) error {
+	return c.JSON(resp.Code.HTTPStatus(), resp)
+}
+
+// policyErrorResponse classifies an error of the policy service. Rule violations carry the
+// failure of every rule as details, exceeded spend limits the usage of the limit and exceeded
+// schedules the time the next proposal is allowed at.
+func policyErrorResponse(err error) ErrorResponse {
+	var (
+		violation  *policy.RuleViolationError
+		limitError *policy.SpendLimitError
+		schedError *policy.ScheduleError
+	)
+	switch {
+	case errors.Is(err, interfaces.ErrPolicyNotFound):
+		return NewCodedErrorResponse(ErrorCodePolicyNotFound, err.Error())
+	case errors.Is(err, policy.ErrPolicyInactive):
+		return NewCodedErrorResponse(ErrorCodePolicyInactive, err.Error())
+	case errors.Is(err, policy.ErrChainNotSupported):
+		return NewCodedErrorResponse(ErrorCodeUnsupportedChain, err.Error())
+	case errors.Is(err, policy.ErrChainMismatch):
+		return NewCodedErrorResponse(ErrorCodeChainMismatch, err.Error())
+	case errors.Is(err, policy.ErrBatchExceedsSchedule):
+		return NewCodedErrorResponse(ErrorCodeRuleViolation, err.Error())
+	case errors.As(err, &violation):
+		resp := NewCodedErrorResponse(ErrorCodeRuleViolation, err.Error())
+		resp.Details = violation.Failures
+		return resp
+	case errors.As(err, &limitError):
+		resp := NewCodedErrorResponse(ErrorCodeSpendLimitExceeded, err.Error())
+		resp.Details = toSpendUsageResponse(limitError.Usage)
+		return resp
+	case errors.As(err, &schedError):
+		resp := NewCodedErrorResponse(ErrorCodeRateLimited, err.Error())
+		resp.Details = ScheduleErrorDetails{
+			MaxTxsPerWindow: schedError.MaxTxs,
+			WindowSeconds:   int64(schedError.Window.Seconds()),
+			Executed:        schedError.Executed,
+			NextAllowedAt:   schedError.NextAllowedAt,
+		}
+		return resp
+	default:
+		return NewCodedErrorResponse(ErrorCodeInternal, "failed to validate transaction against policy")
+	}
+}
+
+// validationMessage turns validator errors into a single message naming the JSON fields.
+func validationMessage(err error) string {
+	var fieldErrors validator.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		return err.Error()
+	}
+
+	msgs := make([]string, 0, len(fieldErrors))
+	for _, fe := range fieldErrors {
+		if fe.Param() != "" {
+			msgs = append(msgs, fmt.Sprintf("%s must satisfy %s=%s", fe.Field(), fe.Tag(), fe.Param()))
+			continue
+		}
+		msgs = append(msgs, fmt.Sprintf("%s must satisfy %s", fe.Field(), fe.Tag()))
+	}
+	return "invalid request: " + strings.Join(msgs, ", ")
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/vultisig/pluginagent/config"
+	"github.com/vultisig/pluginagent/policy"
+	"github.com/vultisig/pluginagent/proposal"
+	vtypes "github.com/vultisig/verifier/types"
+	vgcommon "github.com/vultisig/vultisig-go/common"
+)
+
+// checkFees checks the fees of the proposal against the fee ceiling of the chain narrowed by the
+// policy. The gas limit and fee per gas are capped per transaction, the total fee of a batch adds
+// up. Violations are returned as a proposal.FeeCeilingError, transactions whose fee can't be
+// checked against a ceiling fail with proposal.ErrFeeNotChecked.
+func (s *Server) checkFees(pluginPolicy vtypes.PluginPolicy, chain vgcommon.Chain, txs []proposal.Tx) error {
+	ceiling, err := s.feeCeiling(pluginPolicy, chain)
+	if err != nil {
+		return err
+	}
+
+	txCeiling := ceiling
+	txCeiling.MaxTotalFee = nil
+	total := new(big.Int)
+	for i, tx := range txs {
+		fee, ok := proposal.TransactionFee(tx)
+		if !ok {
+			if ceiling.IsZero() {
+				continue
+			}
+			return fmt.Errorf("%w on %s", proposal.ErrFeeNotChecked, chain.String())
+		}
+		if er := txCeiling.Check(*fee); er != nil {
+			if len(txs) > 1 {
+				return fmt.Errorf("transaction %d: %w", i, er)
+			}
+			return er
+		}
+		if fee.Total != nil {
+			total.Add(total, fee.Total)
+		}
+	}
+	return ceiling.Check(proposal.Fee{Total: total})
+}
+
+// feeCeiling returns the configured ceiling of the chain narrowed by the policy.
+func (s *Server) feeCeiling(pluginPolicy vtypes.PluginPolicy, chain vgcommon.Chain) (proposal.FeeCeiling, error) {
+	var ceiling proposal.FeeCeiling
+	for name, cfg := range s.feeCeilings {
+		if c, err := vgcommon.FromString(name); err == nil && c == chain {
+			parsed, er := parseFeeCeilingConfig(cfg)
+			if er != nil {
+				return proposal.FeeCeiling{}, fmt.Errorf("invalid fee ceiling of chain %s: %w", chain.String(), er)
+			}
+			ceiling = parsed
+			break
+		}
+	}
+
+	recipe, err := pluginPolicy.GetRecipe()
+	if err != nil {
+		return proposal.FeeCeiling{}, fmt.Errorf("failed to get recipe: %w", err)
+	}
+	value, ok := recipe.GetConfiguration().GetFields()[policy.FeeCeilingsKey]
+	if !ok {
+		return ceiling, nil
+	}
+	fields, ok := value.AsInterface().(map[string]any)
+	if !ok {
+		return proposal.FeeCeiling{}, fmt.Errorf("invalid %s of policy %s: expected an object", policy.FeeCeilingsKey, pluginPolicy.ID)
+	}
+	policyCeiling, err := parsePolicyFeeCeiling(fields)
+	if err != nil {
+		return proposal.FeeCeiling{}, fmt.Errorf("invalid %s of policy %s: %w", policy.FeeCeilingsKey, pluginPolicy.ID, err)
+	}
+	return ceiling.Narrow(policyCeiling), nil
+}
+
+func parseFeeCeilingConfig(cfg config.FeeCeilingConfig) (proposal.FeeCeiling, error) {
+	ceiling := proposal.FeeCeiling{MaxGasLimit: cfg.MaxGasLimit}
+	var err error
+	if cfg.MaxFeePerGas != "" {
+		if ceiling.MaxFeePerGas, err = parseFeeAmount(cfg.MaxFeePerGas); err != nil {
+			return proposal.FeeCeiling{}, fmt.Errorf("max_fee_per_gas: %w", err)
+		}
+	}
+	if cfg.MaxTotalFee != "" {
+		if ceiling.MaxTotalFee, err = parseFeeAmount(cfg.MaxTotalFee); err != nil {
+			return proposal.FeeCeiling{}, fmt.Errorf("max_total_fee: %w", err)
+		}
+	}
+	return ceiling, nil
+}
+
+// parsePolicyFeeCeiling parses the fee ceilings of a recipe configuration, amounts can be given
+// as numbers or as decimal strings for values beyond float precision.
+func parsePolicyFeeCeiling(fields map[string]any) (proposal.FeeCeiling, error) {
+	var ceiling proposal.FeeCeiling
+	for name, value := range fields {
+		amount, err := parseFeeAmount(value)
+		if err != nil {
+			return proposal.FeeCeiling{}, fmt.Errorf("%s: %w", name, err)
+		}
+		switch name {
+		case "max_gas_limit":
+			if !amount.IsUint64() {
+				return proposal.FeeCeiling{}, fmt.Errorf("%s: %s is out of range", name, amount.String())
+			}
+			ceiling.MaxGasLimit = amount.Uint64()
+		case "max_fee_per_gas":
+			ceiling.MaxFeePerGas = amount
+		case "max_total_fee":
+			ceiling.MaxTotalFee = amount
+		default:
+			return proposal.FeeCeiling{}, fmt.Errorf("unknown field %s", name)
+		}
+	}
+	return ceiling, nil
+}
+
+func parseFeeAmount(value any) (*big.Int, error) {
+	var amount *big.Int
+	switch v := value.(type) {
+	case string:
+		parsed, ok := new(big.Int).SetString(v, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid amount %q", v)
+		}
+		amount = parsed
+	case float64:
+		parsed, accuracy := big.NewFloat(v).Int(nil)
+		if accuracy != big.Exact {
+			return nil, fmt.Errorf("amount %v is not an integer", v)
+		}
+		amount = parsed
+	default:
+		return nil, fmt.Errorf("invalid amount %v", value)
+	}
+	if amount.Sign() < 0 {
+		return nil, fmt.Errorf("amount %s is negative", amount.String())
+	}
+	return amount, nil
+}
@@ -1,7 +1,9 @@
 package api
 
 import (
+	"crypto/subtle"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -21,3 +23,15 @@ func (s *Server) statsdMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 		return err
 	}
 }
+
+// pluginAuthMiddleware admits requests bearing the API key of the plugin.
+func (s *Server) pluginAuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		token, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+		if !ok || s.pluginCfg.ApiKey == "" ||
+			subtle.ConstantTimeCompare([]byte(token), []byte(s.pluginCfg.ApiKey)) != 1 {
+			return codedError(c, NewCodedErrorResponse(ErrorCodeUnauthorized, "invalid plugin API key"))
+		}
+		return next(c)
+	}
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/vultisig/pluginagent/proposal"
+	"github.com/vultisig/pluginagent/storage/interfaces"
+	vtypes "github.com/vultisig/verifier/types"
+	"github.com/vultisig/vultisig-go/address"
+	vgcommon "github.com/vultisig/vultisig-go/common"
+)
+
+// NonceReservationRequest is the body of POST /nonces/reserve.
+type NonceReservationRequest struct {
+	PolicyID string `json:"policy_id" validate:"required,uuid"`
+	Network  string `json:"network" validate:"required"`
+}
+
+type NonceReservationResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Network   string    `json:"network"`
+	Address   string    `json:"address"`
+	Nonce     uint64    `json:"nonce"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type NonceConflictDetails struct {
+	Nonce      uint64     `json:"nonce"`
+	ProposalID *uuid.UUID `json:"proposal_id,omitempty"`
+}
+
+// ReserveNonce hands out the next free nonce of the vault address of the policy on an EVM chain.
+// The reservation holds the nonce until it expires or a proposal using the nonce is accepted.
+func (s *Server) ReserveNonce(c echo.Context) error {
+	var req NonceReservationRequest
+	if err := c.Bind(&req); err != nil {
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInvalidRequest, "failed to parse request body"))
+	}
+	if err := c.Validate(&req); err != nil {
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInvalidRequest, validationMessage(err)))
+	}
+	// Validated by the uuid tag
+	policyID, _ := uuid.Parse(req.PolicyID)
+
+	chain, err := vgcommon.FromString(req.Network)
+	if err != nil || !chain.IsEvm() {
+		return codedError(c, NewCodedErrorResponse(ErrorCodeUnsupportedChain, fmt.Sprintf("nonces are only managed for EVM networks, got %q", req.Network)))
+	}
+
+	pluginPolicy, err := s.policyService.GetPluginPolicy(c.Request().Context(), policyID)
+	if err != nil {
+		s.logger.WithError(err).WithField("policy_id", policyID).Error("Failed to get plugin policy")
+		return codedError(c, policyErrorResponse(err))
+	}
+
+	from, resp := s.vaultAddress(*pluginPolicy, chain)
+	if resp != nil {
+		return codedError(c, *resp)
+	}
+
+	reservation, err := s.nonces.Reserve(c.Request().Context(), pluginPolicy.ID, chain, from)
+	if err != nil {
+		s.logger.WithError(err).WithField("policy_id", policyID).Error("Failed to reserve nonce")
+		if errors.Is(err, proposal.ErrNoRpcEndpoint) {
+			return codedError(c, NewCodedErrorResponse(ErrorCodeUnsupportedChain, err.Error()))
+		}
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInternal, "failed to reserve nonce"))
+	}
+
+	return c.JSON(http.StatusCreated, NonceReservationResponse{
+		ID:        *reservation.ReservationID,
+		Network:   chain.String(),
+		Address:   reservation.Address,
+		Nonce:     reservation.Nonce,
+		ExpiresAt: *reservation.ExpiresAt,
+	})
+}
+
+// ReleaseNonce frees an unused reservation before it expires. Only the policy the reservation was
+// made for, given as the policy_id query parameter, can release it.
+func (s *Server) ReleaseNonce(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInvalidRequest, "invalid reservation ID"))
+	}
+	policyID, err := uuid.Parse(c.QueryParam("policy_id"))
+	if err != nil {
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInvalidRequest, "invalid policy_id"))
+	}
+
+	if err := s.nonces.Release(c.Request().Context(), id, policyID); err != nil {
+		if errors.Is(err, interfaces.ErrNonceReservationNotFound) {
+			return codedError(c, NewCodedErrorResponse(ErrorCodeReservationNotFound, err.Error()))
+		}
+		s.logger.WithError(err).WithField("reservation_id", id).Error("Failed to release nonce")
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInternal, "failed to release nonce reservation"))
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// claimNonces holds the nonces of the EVM transactions of a new proposal. A nil response means no
+// other live proposal of the vault address uses them.
+func (s *Server) claimNonces(ctx context.Context, policy vtypes.PluginPolicy, proposalID uuid.UUID, txs []proposal.Tx) *ErrorResponse {
+	var (
+		chain  vgcommon.Chain
+		nonces []uint64
+	)
+	for _, tx := range txs {
+		evmTx, ok := tx.(*proposal.EvmTx)
+		if !ok {
+			return nil
+		}
+		chain = evmTx.Chain
+		nonces = append(nonces, evmTx.Tx.Nonce())
+	}
+	if len(nonces) == 0 {
+		return nil
+	}
+
+	from, resp := s.vaultAddress(policy, chain)
+	if resp != nil {
+		return resp
+	}
+
+	err := s.nonces.Claim(ctx, policy.ID, proposalID, chain, from, nonces)
+	if err != nil {
+		s.logger.WithError(err).WithField("proposal_id", proposalID).Error("Failed to claim nonces")
+		var conflict *proposal.NonceConflictError
+		if errors.As(err, &conflict) {
+			resp := NewCodedErrorResponse(ErrorCodeNonceConflict, err.Error())
+			resp.Details = NonceConflictDetails{
+				Nonce:      conflict.Nonce,
+				ProposalID: conflict.ProposalID,
+			}
+			return &resp
+		}
+		resp := NewCodedErrorResponse(ErrorCodeInternal, "failed to claim nonces")
+		return &resp
+	}
+	return nil
+}
+
+// vaultAddress derives the address of the vault of the policy on the chain.
+func (s *Server) vaultAddress(policy vtypes.PluginPolicy, chain vgcommon.Chain) (string, *ErrorResponse) {
+	v, err := s.getVault(policy.PublicKey, policy.PluginID.String())
+	if err != nil {
+		s.logger.WithError(err).WithField("policy_id", policy.ID).Error("Failed to get vault")
+		resp := NewCodedErrorResponse(ErrorCodeSignerUnavailable, "vault is not available for signing")
+		return "", &resp
+	}
+	from, _, _, err := address.GetAddress(v.PublicKeyEcdsa, v.HexChainCode, chain)
+	if err != nil {
+		s.logger.WithError(err).WithField("policy_id", policy.ID).Error("Failed to derive vault address")
+		resp := NewCodedErrorResponse(ErrorCodeInternal, "failed to derive vault address")
+		return "", &resp
+	}
+	return from, nil
+}
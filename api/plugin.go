@@ -2,27 +2,34 @@ package api
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
-	gtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
-	"github.com/sirupsen/logrus"
 	v1 "github.com/vultisig/commondata/go/vultisig/vault/v1"
 	"github.com/vultisig/mobile-tss-lib/tss"
 	"github.com/vultisig/pluginagent/common"
+	"github.com/vultisig/pluginagent/policy"
+	"github.com/vultisig/pluginagent/storage/interfaces"
 	"github.com/vultisig/pluginagent/types"
 	vtypes "github.com/vultisig/verifier/types"
 	vgcommon "github.com/vultisig/vultisig-go/common"
 )
 
 type ErrorResponse struct {
-	Message string `json:"message"`
+	Code    ErrorCode `json:"code,omitempty"`
+	Message string    `json:"message"`
+	Details any       `json:"details,omitempty"`
 }
 
 func NewErrorResponse(message string) ErrorResponse {
@@ -45,6 +52,9 @@ func (s *Server) GetPluginPolicyById(c echo.Context) error {
 	}
 	policy, err := s.policyService.GetPluginPolicy(c.Request().Context(), uPolicyID)
 	if err != nil {
+		if errors.Is(err, interfaces.ErrPolicyNotFound) {
+			return codedError(c, NewCodedErrorResponse(ErrorCodePolicyNotFound, "policy not found"))
+		}
 		s.logger.WithError(err).
 			WithField("policy_id", policyID).
 			Error("fail to get policy from database")
@@ -54,28 +64,120 @@ func (s *Server) GetPluginPolicyById(c echo.Context) error {
 	return c.JSON(http.StatusOK, policy)
 }
 
+const (
+	defaultPoliciesLimit = 50
+	maxPoliciesLimit     = 500
+)
+
+// PluginPoliciesResponse is a page of policies. NextCursor is set when more policies follow, it
+// is passed as the cursor query parameter to get the next page.
+type PluginPoliciesResponse struct {
+	Policies   []types.PluginPolicyRecord `json:"policies"`
+	NextCursor string                     `json:"next_cursor,omitempty"`
+}
+
+// GetAllPluginPolicies lists the policies of a vault newest first. The public_key query parameter
+// is required, so policies are only listed one vault at a time. They are further filtered by the
+// plugin_id, active, from and to query parameters, deleted policies are never listed. Pages are
+// walked with the cursor of the previous page.
 func (s *Server) GetAllPluginPolicies(c echo.Context) error {
-	publicKey := c.Request().Header.Get("public_key")
+	publicKey := c.QueryParam("public_key")
 	if publicKey == "" {
-		return c.JSON(http.StatusBadRequest, NewErrorResponse("missing required header: public_key"))
+		return c.JSON(http.StatusBadRequest, NewErrorResponse("missing required query parameter: public_key"))
+	}
+	deleted := false
+	filter := types.PluginPolicyFilter{
+		PublicKey: &publicKey,
+		Deleted:   &deleted,
+		Limit:     defaultPoliciesLimit,
+	}
+
+	if pluginID := c.QueryParam("plugin_id"); pluginID != "" {
+		id := vtypes.PluginID(pluginID)
+		filter.PluginID = &id
+	}
+	if active := c.QueryParam("active"); active != "" {
+		a, err := strconv.ParseBool(active)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, NewErrorResponse("invalid active, expected true or false"))
+		}
+		filter.Active = &a
+	}
+	if from := c.QueryParam("from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, NewErrorResponse("invalid from, expected RFC3339"))
+		}
+		filter.CreatedFrom = &t
+	}
+	if to := c.QueryParam("to"); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, NewErrorResponse("invalid to, expected RFC3339"))
+		}
+		filter.CreatedTo = &t
+	}
+	if limit := c.QueryParam("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l <= 0 || l > maxPoliciesLimit {
+			return c.JSON(http.StatusBadRequest, NewErrorResponse(fmt.Sprintf("limit must be between 1 and %d", maxPoliciesLimit)))
+		}
+		filter.Limit = l
+	}
+	if cursor := c.QueryParam("cursor"); cursor != "" {
+		after, err := decodePolicyCursor(cursor)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, NewErrorResponse("invalid cursor"))
+		}
+		filter.After = after
+	}
+
+	// One more policy than the page tells whether another page follows
+	pageSize := filter.Limit
+	filter.Limit++
+	policies, err := s.policyService.ListPluginPolicies(c.Request().Context(), filter)
+	if err != nil {
+		s.logger.WithError(err).Error("failed to list policies")
+		return c.JSON(http.StatusInternalServerError, NewErrorResponse("failed to list policies"))
 	}
 
-	pluginID := c.Request().Header.Get("plugin_id")
-	if pluginID == "" {
-		return c.JSON(http.StatusBadRequest, NewErrorResponse("missing required header: plugin_id"))
+	resp := PluginPoliciesResponse{Policies: policies}
+	if len(policies) > pageSize {
+		resp.Policies = policies[:pageSize]
+		last := resp.Policies[pageSize-1]
+		resp.NextCursor = encodePolicyCursor(types.PluginPolicyCursor{CreatedAt: last.CreatedAt, ID: last.ID})
 	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// encodePolicyCursor encodes the cursor as an opaque token, creation times are kept to the
+// microsecond precision of postgres.
+func encodePolicyCursor(cursor types.PluginPolicyCursor) string {
+	raw := fmt.Sprintf("%d:%s", cursor.CreatedAt.UnixMicro(), cursor.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
 
-	policies, err := s.policyService.GetPluginPolicies(c.Request().Context(), vtypes.PluginID(pluginID), publicKey, true)
+func decodePolicyCursor(token string) (*types.PluginPolicyCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
 	if err != nil {
-		s.logger.WithError(err).WithFields(
-			logrus.Fields{
-				"public_key": publicKey,
-				"plugin_id":  pluginID,
-			}).Error("failed to get policies")
-		return c.JSON(http.StatusInternalServerError, NewErrorResponse("failed to get policies"))
+		return nil, fmt.Errorf("failed to decode cursor: %w", err)
 	}
-
-	return c.JSON(http.StatusOK, policies)
+	micros, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+	createdAt, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor time: %w", err)
+	}
+	policyID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor policy ID: %w", err)
+	}
+	return &types.PluginPolicyCursor{
+		CreatedAt: time.UnixMicro(createdAt).UTC(),
+		ID:        policyID,
+	}, nil
 }
 
 func (s *Server) CreatePluginPolicy(c echo.Context) error {
@@ -88,6 +190,10 @@ func (s *Server) CreatePluginPolicy(c echo.Context) error {
 		policy.ID = uuid.New()
 	}
 
+	if resp := s.validatePluginPolicy(policy); resp != nil {
+		return codedError(c, *resp)
+	}
+
 	if !s.verifyPolicySignature(policy) {
 		s.logger.Error("invalid policy signature")
 		return c.JSON(http.StatusForbidden, NewErrorResponse("Invalid policy signature"))
@@ -131,14 +237,9 @@ func (s *Server) UpdatePluginPolicyById(c echo.Context) error {
 		return fmt.Errorf("fail to parse request, err: %w", err)
 	}
 
-	// TODO: validate plugin policy
-	// if err := s.plugin.ValidatePluginPolicy(policy); err != nil {
-	// 	s.logger.WithError(err).
-	// 		WithField("plugin_id", policy.PluginID).
-	// 		WithField("policy_id", policy.ID).
-	// 		Error("Failed to validate plugin policy")
-	// 	return c.JSON(http.StatusBadRequest, NewErrorResponse("failed to validate policy"))
-	// }
+	if resp := s.validatePluginPolicy(policy); resp != nil {
+		return codedError(c, *resp)
+	}
 
 	if !s.verifyPolicySignature(policy) {
 		s.logger.Error("invalid policy signature")
@@ -147,6 +248,18 @@ func (s *Server) UpdatePluginPolicyById(c echo.Context) error {
 
 	updatedPolicy, err := s.policyService.UpdatePolicy(c.Request().Context(), policy)
 	if err != nil {
+		var conflict *interfaces.PolicyVersionConflictError
+		if errors.As(err, &conflict) {
+			resp := NewCodedErrorResponse(ErrorCodeVersionConflict, conflict.Error())
+			resp.Details = VersionConflictDetails{
+				CurrentVersion:   conflict.CurrentVersion,
+				SubmittedVersion: conflict.SubmittedVersion,
+			}
+			return codedError(c, resp)
+		}
+		if errors.Is(err, interfaces.ErrPolicyNotFound) {
+			return codedError(c, NewCodedErrorResponse(ErrorCodePolicyNotFound, "policy not found"))
+		}
 		s.logger.WithError(err).Error("Failed to update plugin policy")
 		return c.JSON(http.StatusInternalServerError, NewErrorResponse("failed to update policy"))
 	}
@@ -176,6 +289,9 @@ func (s *Server) DeletePluginPolicyById(c echo.Context) error {
 	}
 	policy, err := s.policyService.GetPluginPolicy(c.Request().Context(), uPolicyID)
 	if err != nil {
+		if errors.Is(err, interfaces.ErrPolicyNotFound) {
+			return codedError(c, NewCodedErrorResponse(ErrorCodePolicyNotFound, "policy not found"))
+		}
 		s.logger.WithError(err).
 			WithField("policy_id", policyID).
 			Error("Failed to get plugin policy")
@@ -190,12 +306,24 @@ func (s *Server) DeletePluginPolicyById(c echo.Context) error {
 	}
 
 	if err := s.policyService.DeletePolicy(c.Request().Context(), uPolicyID, reqBody.Signature); err != nil {
+		if errors.Is(err, interfaces.ErrPolicyNotFound) {
+			// Deleted concurrently
+			return codedError(c, NewCodedErrorResponse(ErrorCodePolicyNotFound, "policy not found"))
+		}
 		s.logger.WithError(err).
 			WithField("policy_id", policyID).
 			Error("Failed to delete plugin policy")
 		return c.JSON(http.StatusInternalServerError, NewErrorResponse("failed to delete policy"))
 	}
 
+	// The event carries the message to sign to restore the policy
+	deleted, err := s.db.GetDeletedPluginPolicy(c.Request().Context(), uPolicyID)
+	if err != nil {
+		s.logger.WithError(err).WithField("policy_id", policyID).Error("Failed to get deleted policy")
+	} else if err := s.insertPolicyEvent(c.Request().Context(), types.SystemEventTypePluginPolicyDeleted, *deleted); err != nil {
+		s.logger.WithError(err).WithField("policy_id", policyID).Error("Failed to record policy event")
+	}
+
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"policy_id": policyID,
 	})
@@ -211,6 +339,42 @@ func (s *Server) GetRecipeSpecification(c echo.Context) error {
 	return c.Stream(http.StatusOK, "application/json", bytes.NewReader(jsonData))
 }
 
+// validatePluginPolicy checks the policy against the recipe specification of the plugin. The
+// field errors are returned as details of an invalid_policy response. Policy versions are stored
+// as INTEGER, versions out of its range are rejected as an invalid_request.
+func (s *Server) validatePluginPolicy(pluginPolicy vtypes.PluginPolicy) *ErrorResponse {
+	if pluginPolicy.PolicyVersion < 0 || pluginPolicy.PolicyVersion > math.MaxInt32 {
+		resp := NewCodedErrorResponse(ErrorCodeInvalidRequest,
+			fmt.Sprintf("policy_version must be between 0 and %d", math.MaxInt32))
+		return &resp
+	}
+
+	schema, err := policy.LoadRecipeSchema(s.pluginCfg.RecipeSpecificationFilePath)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to load recipe specification")
+		resp := NewCodedErrorResponse(ErrorCodeInternal, "failed to load recipe specification")
+		return &resp
+	}
+
+	err = policy.ValidatePolicy(pluginPolicy, schema)
+	if err == nil {
+		return nil
+	}
+	var validationErr *policy.ValidationError
+	if !errors.As(err, &validationErr) {
+		s.logger.WithError(err).WithField("policy_id", pluginPolicy.ID).Error("Failed to validate plugin policy")
+		resp := NewCodedErrorResponse(ErrorCodeInternal, "failed to validate policy")
+		return &resp
+	}
+	s.logger.WithError(err).
+		WithField("plugin_id", pluginPolicy.PluginID).
+		WithField("policy_id", pluginPolicy.ID).
+		Info("Plugin policy does not satisfy the recipe specification")
+	resp := NewCodedErrorResponse(ErrorCodeInvalidPolicy, "policy does not satisfy the recipe specification")
+	resp.Details = validationErr.Errors
+	return &resp
+}
+
 func (s *Server) verifyPolicySignature(policy vtypes.PluginPolicy) bool {
 	msgBytes, err := common.PolicyToMessageHex(policy)
 	if err != nil {
@@ -218,12 +382,22 @@ func (s *Server) verifyPolicySignature(policy vtypes.PluginPolicy) bool {
 		return false
 	}
 
-	signatureBytes, err := hex.DecodeString(strings.TrimPrefix(policy.Signature, "0x"))
+	return s.verifyVaultSignature(policy.PublicKey, policy.PluginID.String(), msgBytes, policy.Signature)
+}
+
+// verifyVaultSignature checks a hex encoded signature of the message by the Ethereum key derived
+// from the vault.
+func (s *Server) verifyVaultSignature(publicKeyECDSA, pluginID string, msg []byte, signature string) bool {
+	signatureBytes, err := hex.DecodeString(strings.TrimPrefix(signature, "0x"))
 	if err != nil {
 		s.logger.WithError(err).Error("Failed to decode signature bytes")
 		return false
 	}
-	vault, err := s.getVault(policy.PublicKey, policy.PluginID.String())
+	if len(signatureBytes) < 64 {
+		s.logger.Error("Signature is too short")
+		return false
+	}
+	vault, err := s.getVault(publicKeyECDSA, pluginID)
 	if err != nil {
 		s.logger.WithError(err).Error("fail to get vault")
 		return false
@@ -234,7 +408,7 @@ func (s *Server) verifyPolicySignature(policy vtypes.PluginPolicy) bool {
 		return false
 	}
 
-	isVerified, err := common.VerifyPolicySignature(derivedPublicKey, msgBytes, signatureBytes)
+	isVerified, err := common.VerifyPolicySignature(derivedPublicKey, msg, signatureBytes)
 	if err != nil {
 		s.logger.WithError(err).Error("Failed to verify signature")
 		return false
@@ -259,21 +433,3 @@ func (s *Server) getVault(publicKeyECDSA, pluginId string) (*v1.Vault, error) {
 	}
 	return v, nil
 }
-
-func calculateTransactionHash(txData string) (string, error) {
-	tx := &gtypes.Transaction{}
-	rawTx, err := hex.DecodeString(txData)
-	if err != nil {
-		return "", fmt.Errorf("invalid transaction hex: %w", err)
-	}
-
-	err = tx.UnmarshalBinary(rawTx)
-	if err != nil {
-		return "", fmt.Errorf("failed to unmarshal transaction: %w", err)
-	}
-
-	chainID := tx.ChainId()
-	signer := gtypes.NewEIP155Signer(chainID)
-	hash := signer.Hash(tx).String()[2:]
-	return hash, nil
-}
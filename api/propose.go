@@ -1,72 +1,605 @@
 package api
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
 	"github.com/labstack/echo/v4"
-	"github.com/vultisig/mobile-tss-lib/tss"
-	vtypes "github.com/vultisig/verifier/types"
+	"github.com/vultisig/pluginagent/policy"
+	"github.com/vultisig/pluginagent/proposal"
+	"github.com/vultisig/pluginagent/storage/interfaces"
+	"github.com/vultisig/pluginagent/types"
 	vgcommon "github.com/vultisig/vultisig-go/common"
 )
 
+type BroadcastResult struct {
+	TxHash string `json:"tx_hash,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ProposalTxResponse is a transaction of a proposal. Batch proposals list every transaction in
+// transactions, single transaction proposals inline the fields.
+type ProposalTxResponse struct {
+	TxHex         string           `json:"tx_hex,omitempty"`
+	TxType        proposal.TxType  `json:"tx_type,omitempty"`
+	SigningHash   string           `json:"signing_hash,omitempty"`
+	SigningHashes []string         `json:"signing_hashes,omitempty"`
+	SignedTxHex   string           `json:"signed_tx_hex,omitempty"`
+	TxHash        string           `json:"tx_hash,omitempty"`
+	Broadcast     *BroadcastResult `json:"broadcast,omitempty"`
+}
+
 type ProposalResponse struct {
-	PolicyID  string              `json:"policy_id"`
-	Network   string              `json:"network"`
-	TxHex     string              `json:"tx_hex"`
-	Signature tss.KeysignResponse `json:"signature"`
+	ID        string               `json:"id"`
+	Status    types.ProposalStatus `json:"status"`
+	PolicyID  string               `json:"policy_id"`
+	PublicKey string               `json:"public_key"`
+	Network   string               `json:"network"`
+	ProposalTxResponse
+	Transactions []ProposalTxResponse `json:"transactions,omitempty"`
+	// Signatures are keyed by keysign message hash, in keysign message order
+	Signatures *KeyedSignatures `json:"signatures,omitempty"`
+	Error      string           `json:"error,omitempty"`
+	// ApprovalExpiresAt is set on proposals that need the approval of the vault owner
+	ApprovalExpiresAt *time.Time `json:"approval_expires_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// proposalIdempotencyKey scopes the client supplied key to the policy, falling back to the
+// transaction digest (EVM signing hash or UTXO txid) when no key is given.
+func proposalIdempotencyKey(policyID uuid.UUID, clientKey, txDigest string) string {
+	if clientKey != "" {
+		return fmt.Sprintf("key:%s:%s", policyID, clientKey)
+	}
+	return fmt.Sprintf("tx:%s:%s", policyID, txDigest)
+}
+
+// proposalRequestDigest identifies what a proposal was requested for, the transaction digest
+// along with how the outcome is delivered.
+func proposalRequestDigest(chain vgcommon.Chain, txDigest string, broadcast bool, callbackURL string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%s:%t:%s", chain.String(), txDigest, broadcast, callbackURL)))
+	return hex.EncodeToString(sum[:])
+}
+
+// ProposeRequest is the body of POST /propose. Exactly one transaction encoding must be set:
+// tx_hex (EVM transaction or PSBT), psbt (base64 PSBT), message (base64 Solana message), cosmos
+// or transactions, a batch of hex encoded payloads that are validated and signed together.
+type ProposeRequest struct {
+	PolicyID     string                  `json:"policy_id" validate:"required,uuid"`
+	Network      string                  `json:"network" validate:"required"`
+	TxHex        string                  `json:"tx_hex,omitempty" validate:"omitempty,hexadecimal"`
+	Psbt         string                  `json:"psbt,omitempty" validate:"omitempty,base64"`
+	Message      string                  `json:"message,omitempty" validate:"omitempty,base64"`
+	Cosmos       *proposal.CosmosPayload `json:"cosmos,omitempty"`
+	Transactions []string                `json:"transactions,omitempty"`
+	// Broadcast submits the signed transaction through the chain RPC, EVM chains only
+	Broadcast bool `json:"broadcast"`
+	// CallbackURL is called once signing completes or fails, instead of the URL configured for
+	// the plugin. It must be https and resolve to public addresses only
+	CallbackURL string `json:"callback_url,omitempty" validate:"omitempty,url"`
+}
+
+// txPayloads returns the raw transaction payloads as stored in the proposal, in signing order.
+func (r *ProposeRequest) txPayloads() ([][]byte, error) {
+	var (
+		payload []byte
+		set     int
+		err     error
+	)
+	if len(r.Transactions) > 0 {
+		payloads := make([][]byte, 0, len(r.Transactions))
+		for i, txHex := range r.Transactions {
+			p, er := hex.DecodeString(strings.TrimPrefix(txHex, "0x"))
+			if er != nil {
+				return nil, fmt.Errorf("failed to decode transactions[%d]: %w", i, er)
+			}
+			payloads = append(payloads, p)
+		}
+		if r.TxHex != "" || r.Psbt != "" || r.Message != "" || r.Cosmos != nil {
+			return nil, fmt.Errorf("exactly one of tx_hex, psbt, message, cosmos or transactions is required")
+		}
+		return payloads, nil
+	}
+	if r.TxHex != "" {
+		set++
+		// Strip 0x from the tx hex
+		if payload, err = hex.DecodeString(strings.TrimPrefix(r.TxHex, "0x")); err != nil {
+			return nil, fmt.Errorf("failed to decode tx_hex: %w", err)
+		}
+	}
+	if r.Psbt != "" {
+		set++
+		if payload, err = base64.StdEncoding.DecodeString(r.Psbt); err != nil {
+			return nil, fmt.Errorf("failed to decode psbt, expected base64")
+		}
+	}
+	if r.Message != "" {
+		set++
+		if payload, err = base64.StdEncoding.DecodeString(r.Message); err != nil {
+			return nil, fmt.Errorf("failed to decode message, expected base64")
+		}
+	}
+	if r.Cosmos != nil {
+		set++
+		cosmos := *r.Cosmos
+		if cosmos.SignMode == "" {
+			cosmos.SignMode = proposal.CosmosSignModeDirect
+		}
+		if payload, err = json.Marshal(cosmos); err != nil {
+			return nil, fmt.Errorf("failed to encode cosmos payload: %w", err)
+		}
+	}
+	if set != 1 {
+		return nil, fmt.Errorf("exactly one of tx_hex, psbt, message, cosmos or transactions is required")
+	}
+	return [][]byte{payload}, nil
 }
 
+// Propose validates the transaction against the policy and queues it for signing.
+// The returned proposal ID can be polled through GetProposal. Submitting the same transaction
+// for the same policy, or reusing an Idempotency-Key, returns the existing proposal unless it failed.
+// An Idempotency-Key reused with a different request is rejected.
+// Proposals above an approval threshold are held in pending_approval until the vault owner
+// approves them. Failures are reported with an ErrorCode.
 func (s *Server) Propose(c echo.Context) error {
-	policyID := c.QueryParam("policy_id")
-	network := c.QueryParam("network")
-	txHex := c.QueryParam("tx_hex")
-	// Strip 0x from the tx hex
-	txHex = strings.TrimPrefix(txHex, "0x")
+	var req ProposeRequest
+	if err := c.Bind(&req); err != nil {
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInvalidRequest, "failed to parse request body"))
+	}
+	if err := c.Validate(&req); err != nil {
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInvalidRequest, validationMessage(err)))
+	}
+	payloads, err := req.txPayloads()
+	if err != nil {
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInvalidRequest, err.Error()))
+	}
+	if err := s.checkCallbackURL(c.Request().Context(), req.CallbackURL); err != nil {
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInvalidRequest, err.Error()))
+	}
+	// Validated by the uuid tag
+	policyID, _ := uuid.Parse(req.PolicyID)
 
-	policy, err := s.policyService.GetPluginPolicy(c.Request().Context(), uuid.MustParse(policyID))
+	chain, err := vgcommon.FromString(req.Network)
 	if err != nil {
-		s.logger.WithError(err).Error("Failed to get plugin policy")
-		return c.JSON(http.StatusInternalServerError, NewErrorResponse("failed to get plugin policy"))
+		return codedError(c, NewCodedErrorResponse(ErrorCodeUnsupportedChain, fmt.Sprintf("unknown network %q", req.Network)))
+	}
+	// Only EVM transactions have a broadcaster
+	if req.Broadcast && !chain.IsEvm() {
+		return codedError(c, NewCodedErrorResponse(ErrorCodeUnsupportedChain, fmt.Sprintf("broadcast is not supported on %s, only on EVM chains", chain.String())))
 	}
 
-	tx, err := hex.DecodeString(txHex)
+	var (
+		txs    []proposal.Tx
+		digest string
+	)
+	if len(req.Transactions) > 0 {
+		batch, er := proposal.DecodeBatch(chain, payloads)
+		if er != nil {
+			s.logger.WithError(er).Error("Failed to decode batch")
+			return codedError(c, NewCodedErrorResponse(ErrorCodeInvalidTransaction, fmt.Sprintf("failed to decode batch: %v", er)))
+		}
+		txs = batch.Txs
+		digest = batch.Digest()
+	} else {
+		decoded, er := proposal.DecodeTx(chain, payloads[0])
+		if er != nil {
+			s.logger.WithError(er).Error("Failed to decode transaction")
+			return codedError(c, NewCodedErrorResponse(ErrorCodeInvalidTransaction, fmt.Sprintf("failed to decode transaction: %v", er)))
+		}
+		txs = []proposal.Tx{decoded}
+		digest = decoded.Digest()
+	}
+
+	pluginPolicy, err := s.policyService.GetPluginPolicy(c.Request().Context(), policyID)
 	if err != nil {
-		s.logger.WithError(err).Error("Failed to decode tx hex")
-		return c.JSON(http.StatusInternalServerError, NewErrorResponse("failed to decode tx hex"))
+		s.logger.WithError(err).WithField("policy_id", policyID).Error("Failed to get plugin policy")
+		return codedError(c, policyErrorResponse(err))
 	}
 
-	chain, err := vgcommon.FromString(network)
+	// Every transaction must be allowed by one of the policy rules before any TSS session is
+	// started, a batch is rejected as a whole
+	allowed := make([]policy.AllowedTx, 0, len(txs))
+	for i, tx := range txs {
+		rule, er := s.policyService.ValidateTransaction(*pluginPolicy, chain, tx.PolicyPayload())
+		if er != nil {
+			if len(txs) > 1 {
+				er = fmt.Errorf("transaction %d: %w", i, er)
+			}
+			s.logger.WithError(er).WithField("policy_id", policyID).Error("Transaction rejected by policy")
+			return codedError(c, policyErrorResponse(er))
+		}
+		allowed = append(allowed, policy.AllowedTx{Rule: rule, Payload: tx.PolicyPayload()})
+	}
+	// The allowing rules are logged by the key rule violations identify them with
+	recipe, err := pluginPolicy.GetRecipe()
 	if err != nil {
-		s.logger.WithError(err).Error("Failed to get chain from network")
-		return c.JSON(http.StatusInternalServerError, NewErrorResponse("failed to get chain from network"))
+		s.logger.WithError(err).WithField("policy_id", policyID).Error("Failed to decode policy recipe")
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInternal, "failed to decode policy recipe"))
+	}
+	for i, tx := range allowed {
+		s.logger.WithField("policy_id", policyID).
+			WithField("rule_id", policy.RecipeRuleKey(recipe, tx.Rule)).
+			WithField("tx_index", i).
+			Info("Transaction allowed by policy")
+	}
+
+	clientKey := c.Request().Header.Get(IdempotencyKeyHeader)
+	p := types.Proposal{
+		ID:             uuid.New(),
+		PolicyID:       pluginPolicy.ID,
+		PublicKey:      pluginPolicy.PublicKey,
+		Chain:          chain,
+		Broadcast:      req.Broadcast,
+		Status:         types.ProposalStatusQueued,
+		IdempotencyKey: proposalIdempotencyKey(pluginPolicy.ID, clientKey, digest),
+		RequestDigest:  proposalRequestDigest(chain, digest, req.Broadcast, req.CallbackURL),
+	}
+	if len(req.Transactions) > 0 {
+		for _, payload := range payloads {
+			p.Batch = append(p.Batch, types.ProposalTx{TxHex: hex.EncodeToString(payload)})
+		}
+	} else {
+		p.TxHex = hex.EncodeToString(payloads[0])
+	}
+	if req.CallbackURL != "" {
+		p.CallbackURL = &req.CallbackURL
 	}
 
-	signRequest, e := vtypes.NewPluginKeysignRequestEvm(
-		*policy, "", chain, tx)
-	if e != nil {
-		s.logger.WithError(e).Error("Failed to create unsigned request")
-		return c.JSON(http.StatusInternalServerError, NewErrorResponse(fmt.Sprintf("failed to create unsigned request: %v", e)))
+	if err := s.checkFees(*pluginPolicy, chain, txs); err != nil {
+		if errors.Is(err, proposal.ErrFeeNotChecked) {
+			s.logger.WithError(err).WithField("policy_id", policyID).Error("Fee ceiling can't be checked")
+			return codedError(c, NewCodedErrorResponse(ErrorCodeUnsupportedChain, err.Error()))
+		}
+		var ceilingErr *proposal.FeeCeilingError
+		if !errors.As(err, &ceilingErr) {
+			s.logger.WithError(err).WithField("policy_id", policyID).Error("Failed to check fee ceilings")
+			return codedError(c, NewCodedErrorResponse(ErrorCodeInternal, "failed to check fee ceilings"))
+		}
+		s.logger.WithError(err).WithField("policy_id", policyID).Error("Transaction fee above ceiling")
+		resp := NewCodedErrorResponse(ErrorCodeFeeCeilingExceeded, err.Error())
+		details := FeeCeilingDetails{Violations: ceilingErr.Violations}
+		// The violation is recorded on a failed proposal, so it shows up in the proposal ledger
+		if rejected := s.insertRejectedProposal(c.Request().Context(), p, err.Error()); rejected != nil {
+			details.ProposalID = &rejected.ID
+		}
+		resp.Details = details
+		return codedError(c, resp)
 	}
 
-	signatures, err := s.signer.Sign(c.Request().Context(), *signRequest)
+	vaultExists, err := s.vaultStorage.Exist(vgcommon.GetVaultBackupFilename(pluginPolicy.PublicKey, pluginPolicy.PluginID.String()))
+	if err != nil || !vaultExists {
+		s.logger.WithError(err).WithField("policy_id", policyID).Error("Vault is not available for signing")
+		return codedError(c, NewCodedErrorResponse(ErrorCodeSignerUnavailable, "vault is not available for signing"))
+	}
+
+	// Reverting transactions are rejected before they are recorded or reach a TSS session. Only
+	// the first transaction of a batch is simulated, the others depend on its state changes.
+	if resp := s.simulateProposal(c.Request().Context(), *pluginPolicy, txs[0]); resp != nil {
+		return codedError(c, *resp)
+	}
+
+	// Proposals above an approval threshold wait for the vault owner instead of being signed
+	thresholds, err := s.approvalThresholds(chain, txs)
+	if errors.Is(err, policy.ErrValueNotSupported) {
+		s.logger.WithError(err).WithField("policy_id", policyID).Error("Approval thresholds are configured for a chain without a value decoder")
+		return codedError(c, NewCodedErrorResponse(ErrorCodeUnsupportedChain, fmt.Sprintf("approval thresholds can't be checked on %s", chain.String())))
+	}
 	if err != nil {
-		s.logger.WithError(err).Error("Failed to sign request")
-		return c.JSON(http.StatusInternalServerError, NewErrorResponse("failed to sign request"))
+		s.logger.WithError(err).WithField("policy_id", policyID).Error("Failed to check approval thresholds")
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInvalidTransaction, fmt.Sprintf("failed to get transaction value: %v", err)))
+	}
+	if len(thresholds) > 0 {
+		expiresAt := time.Now().UTC().Add(s.approvalExpiry())
+		p.Status = types.ProposalStatusPendingApproval
+		p.ApprovalExpiresAt = &expiresAt
 	}
 
-	var sig tss.KeysignResponse
-	for _, s := range signatures {
-		sig = s
+	newProposal, created, err := s.db.InsertProposal(c.Request().Context(), p)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to insert proposal")
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInternal, "failed to create proposal"))
+	}
+	if !created {
+		// A client key reused for another request must not pass for the earlier proposal.
+		// Proposals stored before request digests were recorded have none.
+		if clientKey != "" && newProposal.RequestDigest != "" && newProposal.RequestDigest != p.RequestDigest {
+			s.logger.WithField("proposal_id", newProposal.ID).Error("Idempotency key reused for a different request")
+			return codedError(c, NewCodedErrorResponse(ErrorCodeIdempotencyMismatch, "idempotency key was already used for a different request"))
+		}
+		// A retry of a proposal that is in flight or already signed, don't start another keysign
+		s.logger.WithField("proposal_id", newProposal.ID).
+			WithField("status", newProposal.Status).
+			Info("Returning existing proposal for idempotency key")
+		c.Response().Header().Set(IdempotentReplayedHeader, "true")
+		return c.JSON(http.StatusOK, s.toProposalResponse(*newProposal))
+	}
+
+	// Nonces are claimed once the proposal exists, the nonce of a proposal that fails is free again
+	if resp := s.claimNonces(c.Request().Context(), *pluginPolicy, newProposal.ID, txs); resp != nil {
+		s.failProposal(c.Request().Context(), newProposal.ID, resp.Message)
+		return codedError(c, *resp)
+	}
+
+	// The schedule and spend limits are checked and reserved once the proposal exists, so a retry
+	// of an accepted proposal doesn't count twice
+	err = s.policyService.ReserveProposal(c.Request().Context(), *pluginPolicy, *newProposal, allowed)
+	if err != nil {
+		s.logger.WithError(err).WithField("proposal_id", newProposal.ID).Error("Failed to reserve proposal")
+		s.failProposal(c.Request().Context(), newProposal.ID, err.Error())
+		var scheduleErr *policy.ScheduleError
+		if errors.As(err, &scheduleErr) {
+			retryAfter := int(math.Ceil(time.Until(scheduleErr.NextAllowedAt).Seconds()))
+			c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(max(retryAfter, 1)))
+		}
+		return codedError(c, policyErrorResponse(err))
+	}
+
+	if newProposal.Status == types.ProposalStatusPendingApproval {
+		err = s.insertApprovalEvent(c.Request().Context(), types.SystemEventTypeProposalPendingApproval, *newProposal, thresholds)
+		if err != nil {
+			s.logger.WithError(err).WithField("proposal_id", newProposal.ID).Error("Failed to announce pending approval")
+			s.failProposal(c.Request().Context(), newProposal.ID, err.Error())
+			return codedError(c, NewCodedErrorResponse(ErrorCodeInternal, "failed to announce pending approval"))
+		}
+		s.logger.WithField("proposal_id", newProposal.ID).Info("Proposal is pending approval")
+		return c.JSON(http.StatusAccepted, s.toProposalResponse(*newProposal))
+	}
+
+	if resp := s.enqueueProposal(c.Request().Context(), *newProposal); resp != nil {
+		return codedError(c, *resp)
+	}
+
+	return c.JSON(http.StatusAccepted, s.toProposalResponse(*newProposal))
+}
+
+// enqueueProposal queues a proposal for signing. A proposal that can't be queued is failed.
+func (s *Server) enqueueProposal(ctx context.Context, p types.Proposal) *ErrorResponse {
+	buf, err := json.Marshal(proposal.SignTaskPayload{ProposalID: p.ID})
+	if err != nil {
+		s.failProposal(ctx, p.ID, fmt.Sprintf("failed to marshal sign task: %v", err))
+		resp := NewCodedErrorResponse(ErrorCodeInternal, "failed to enqueue proposal")
+		return &resp
+	}
+	_, err = s.client.EnqueueContext(ctx,
+		asynq.NewTask(proposal.TypeProposalSign, buf),
+		asynq.TaskID(p.ID.String()),
+		asynq.MaxRetry(0),
+		asynq.Timeout(proposal.SignTimeout),
+		asynq.Retention(10*time.Minute),
+		asynq.Queue(proposal.QUEUE_NAME))
+	if err != nil {
+		s.logger.WithError(err).WithField("proposal_id", p.ID).Error("Failed to enqueue proposal")
+		s.failProposal(ctx, p.ID, fmt.Sprintf("failed to enqueue proposal: %v", err))
+		resp := NewCodedErrorResponse(ErrorCodeSignerUnavailable, "failed to enqueue proposal")
+		return &resp
+	}
+	return nil
+}
+
+// insertRejectedProposal stores a proposal that was rejected before it was accepted as failed.
+// Nothing is stored if a live proposal holds the idempotency key already.
+func (s *Server) insertRejectedProposal(ctx context.Context, p types.Proposal, errMsg string) *types.Proposal {
+	rejected, created, err := s.db.InsertProposal(ctx, p)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to insert rejected proposal")
+		return nil
+	}
+	if !created {
+		return nil
+	}
+	s.failProposal(ctx, rejected.ID, errMsg)
+	return rejected
+}
+
+// failProposal marks a proposal that was rejected before signing as failed, which frees its
+// idempotency key.
+func (s *Server) failProposal(ctx context.Context, id uuid.UUID, errMsg string) {
+	if err := s.db.UpdateProposalStatus(ctx, id, types.ProposalStatusFailed, &errMsg); err != nil {
+		s.logger.WithError(err).WithField("proposal_id", id).Error("Failed to mark proposal as failed")
+	}
+}
+
+func (s *Server) GetProposal(c echo.Context) error {
+	proposalID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, NewErrorResponse("invalid proposal ID"))
+	}
+
+	p, err := s.db.GetProposal(c.Request().Context(), proposalID)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrProposalNotFound) {
+			return c.JSON(http.StatusNotFound, NewErrorResponse("proposal not found"))
+		}
+		s.logger.WithError(err).WithField("proposal_id", proposalID).Error("Failed to get proposal")
+		return c.JSON(http.StatusInternalServerError, NewErrorResponse("failed to get proposal"))
+	}
+
+	return c.JSON(http.StatusOK, s.toProposalResponse(*p))
+}
+
+const (
+	defaultProposalsLimit = 50
+	maxProposalsLimit     = 500
+)
+
+// ListProposals returns the proposal ledger, newest first. It can be filtered by policy_id,
+// public_key (vault), status and a from/to creation time range given in RFC3339.
+func (s *Server) ListProposals(c echo.Context) error {
+	filter := types.ProposalFilter{
+		Limit: defaultProposalsLimit,
+	}
+
+	if policyID := c.QueryParam("policy_id"); policyID != "" {
+		id, err := uuid.Parse(policyID)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, NewErrorResponse("invalid policy_id"))
+		}
+		filter.PolicyID = &id
+	}
+	if publicKey := c.QueryParam("public_key"); publicKey != "" {
+		filter.PublicKey = &publicKey
+	}
+	if status := c.QueryParam("status"); status != "" {
+		proposalStatus := types.ProposalStatus(status)
+		switch proposalStatus {
+		case types.ProposalStatusQueued,
+			types.ProposalStatusPendingApproval,
+			types.ProposalStatusSigning,
+			types.ProposalStatusSigned,
+			types.ProposalStatusFailed:
+		default:
+			return c.JSON(http.StatusBadRequest, NewErrorResponse("invalid status"))
+		}
+		filter.Status = &proposalStatus
+	}
+	if from := c.QueryParam("from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, NewErrorResponse("invalid from, expected RFC3339"))
+		}
+		t = t.UTC()
+		filter.CreatedFrom = &t
+	}
+	if to := c.QueryParam("to"); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, NewErrorResponse("invalid to, expected RFC3339"))
+		}
+		t = t.UTC()
+		filter.CreatedTo = &t
+	}
+	if limit := c.QueryParam("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l <= 0 || l > maxProposalsLimit {
+			return c.JSON(http.StatusBadRequest, NewErrorResponse(fmt.Sprintf("limit must be between 1 and %d", maxProposalsLimit)))
+		}
+		filter.Limit = l
+	}
+	if offset := c.QueryParam("offset"); offset != "" {
+		o, err := strconv.Atoi(offset)
+		if err != nil || o < 0 {
+			return c.JSON(http.StatusBadRequest, NewErrorResponse("invalid offset"))
+		}
+		filter.Offset = o
+	}
+
+	proposals, err := s.db.ListProposals(c.Request().Context(), filter)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list proposals")
+		return c.JSON(http.StatusInternalServerError, NewErrorResponse("failed to list proposals"))
+	}
+
+	resp := make([]ProposalResponse, 0, len(proposals))
+	for _, p := range proposals {
+		resp = append(resp, s.toProposalResponse(p))
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) toProposalResponse(p types.Proposal) ProposalResponse {
+	resp := ProposalResponse{
+		ID:        p.ID.String(),
+		Status:    p.Status,
+		PolicyID:  p.PolicyID.String(),
+		PublicKey: p.PublicKey,
+		Network:   p.Chain.String(),
+		CreatedAt: p.CreatedAt,
+		UpdatedAt: p.UpdatedAt,
+	}
+
+	var hashOrder []string
+	if len(p.Batch) == 0 {
+		var hashes []string
+		resp.ProposalTxResponse, hashes = toProposalTxResponse(p.Chain, types.ProposalTx{
+			TxHex:           p.TxHex,
+			SignedTxHex:     p.SignedTxHex,
+			TxHash:          p.TxHash,
+			BroadcastTxHash: p.BroadcastTxHash,
+			BroadcastError:  p.BroadcastError,
+		})
+		hashOrder = hashes
+	} else {
+		for _, tx := range p.Batch {
+			txResp, hashes := toProposalTxResponse(p.Chain, tx)
+			resp.Transactions = append(resp.Transactions, txResp)
+			hashOrder = append(hashOrder, hashes...)
+		}
+	}
+	if len(p.Signatures) > 0 {
+		resp.Signatures = &KeyedSignatures{
+			Order:      hashOrder,
+			Signatures: p.Signatures,
+		}
+	}
+	if p.Error != nil {
+		resp.Error = *p.Error
+	}
+	resp.ApprovalExpiresAt = p.ApprovalExpiresAt
+
+	return resp
+}
+
+// toProposalTxResponse describes a transaction of a proposal and returns the keysign message
+// hashes its signatures are keyed by.
+func toProposalTxResponse(chain vgcommon.Chain, tx types.ProposalTx) (ProposalTxResponse, []string) {
+	resp := ProposalTxResponse{
+		TxHex: tx.TxHex,
+	}
+
+	var keysignHashes []string
+	if raw, err := hex.DecodeString(tx.TxHex); err == nil {
+		if decoded, er := proposal.DecodeTx(chain, raw); er == nil {
+			resp.TxType = decoded.Type()
+			keysignHashes, _ = proposal.KeysignMessageHashes(decoded)
+			switch t := decoded.(type) {
+			case *proposal.EvmTx:
+				resp.SigningHash = t.SigningHash().Hex()
+			case *proposal.CosmosTx:
+				resp.SigningHash = t.Digest()
+			case *proposal.SolanaTx:
+				// The signed payload is the message in tx_hex
+			default:
+				if hashes, e := decoded.SigningHashes(); e == nil {
+					for _, hash := range hashes {
+						resp.SigningHashes = append(resp.SigningHashes, hex.EncodeToString(hash))
+					}
+				}
+			}
+		}
+	}
+	if tx.SignedTxHex != nil {
+		resp.SignedTxHex = *tx.SignedTxHex
+	}
+	if tx.TxHash != nil {
+		resp.TxHash = *tx.TxHash
+	}
+	if tx.BroadcastTxHash != nil || tx.BroadcastError != nil {
+		resp.Broadcast = &BroadcastResult{}
+		if tx.BroadcastTxHash != nil {
+			resp.Broadcast.TxHash = *tx.BroadcastTxHash
+		}
+		if tx.BroadcastError != nil {
+			resp.Broadcast.Error = *tx.BroadcastError
+		}
 	}
 
-	return c.JSON(http.StatusOK, ProposalResponse{
-		PolicyID:  policyID,
-		Network:   network,
-		TxHex:     txHex,
-		Signature: sig,
-	})
+	return resp, keysignHashes
 }
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/vultisig/pluginagent/storage/interfaces"
+	"github.com/vultisig/pluginagent/types"
+)
+
+const (
+	defaultRestoreWindow = 7 * 24 * time.Hour
+	defaultPurgeAfter    = 30 * 24 * time.Hour
+	policyPurgeInterval  = time.Hour
+
+	policyActionRestore = "restore"
+)
+
+// RestorePolicyRequest is the body of POST /plugin/policy/:policyId/restore, signed like an
+// approval by the vault owner.
+type RestorePolicyRequest struct {
+	Signature string `json:"signature" validate:"required"`
+}
+
+// PolicyEventData is the data of policy_deleted, policy_restored and policy_purged events.
+// Deleted policies carry the message the owner signs to restore them before RestoreBefore.
+type PolicyEventData struct {
+	PolicyID       string     `json:"policy_id"`
+	PluginID       string     `json:"plugin_id"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty"`
+	RestoreBefore  *time.Time `json:"restore_before,omitempty"`
+	RestoreMessage string     `json:"restore_message,omitempty"`
+}
+
+// restoreMessage is the message the vault owner signs to restore a deleted policy. It binds the
+// restore to the deletion, so a signature doesn't restore the policy once deleted again.
+func restoreMessage(policy types.PluginPolicyRecord) string {
+	var deletedAt string
+	if policy.DeletedAt != nil {
+		deletedAt = strconv.FormatInt(policy.DeletedAt.UnixMicro(), 10)
+	}
+	return strings.Join([]string{policyActionRestore, policy.ID.String(), deletedAt}, "*#*")
+}
+
+func (s *Server) restoreWindow() time.Duration {
+	if s.retentionCfg.RestoreWindow > 0 {
+		return s.retentionCfg.RestoreWindow
+	}
+	return defaultRestoreWindow
+}
+
+func (s *Server) purgeAfter() time.Duration {
+	if s.retentionCfg.PurgeAfter > 0 {
+		return s.retentionCfg.PurgeAfter
+	}
+	return defaultPurgeAfter
+}
+
+func (s *Server) insertPolicyEvent(ctx context.Context, eventType types.SystemEventType, policy types.PluginPolicyRecord) error {
+	data := PolicyEventData{
+		PolicyID: policy.ID.String(),
+		PluginID: policy.PluginID.String(),
+	}
+	if policy.Deleted {
+		data.DeletedAt = policy.DeletedAt
+	}
+	if eventType == types.SystemEventTypePluginPolicyDeleted && policy.DeletedAt != nil {
+		restoreBefore := policy.DeletedAt.Add(s.restoreWindow())
+		data.RestoreBefore = &restoreBefore
+		data.RestoreMessage = restoreMessage(policy)
+	}
+	eventData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event data: %w", err)
+	}
+
+	_, err = s.db.InsertEvent(ctx, &types.SystemEvent{
+		PublicKey: &policy.PublicKey,
+		PolicyID:  &policy.ID,
+		EventType: eventType,
+		EventData: eventData,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to insert event: %w", err)
+	}
+	return nil
+}
+
+// RestorePluginPolicy undeletes a policy deleted within the restore window, on the signature of
+// the vault owner over the restore message of the policy_deleted event.
+func (s *Server) RestorePluginPolicy(c echo.Context) error {
+	policyID, err := uuid.Parse(c.Param("policyId"))
+	if err != nil {
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInvalidRequest, "invalid policy ID"))
+	}
+
+	var req RestorePolicyRequest
+	if err := c.Bind(&req); err != nil {
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInvalidRequest, "failed to parse request body"))
+	}
+	if err := c.Validate(&req); err != nil {
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInvalidRequest, validationMessage(err)))
+	}
+
+	ctx := c.Request().Context()
+	deleted, err := s.db.GetDeletedPluginPolicy(ctx, policyID)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrPolicyNotFound) {
+			return codedError(c, NewCodedErrorResponse(ErrorCodePolicyNotFound, "deleted policy not found"))
+		}
+		s.logger.WithError(err).WithField("policy_id", policyID).Error("Failed to get deleted policy")
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInternal, "failed to get deleted policy"))
+	}
+
+	restoreAfter := time.Now().UTC().Add(-s.restoreWindow())
+	if deleted.DeletedAt == nil || deleted.DeletedAt.Before(restoreAfter) {
+		return codedError(c, NewCodedErrorResponse(ErrorCodeRestoreExpired, "policy can no longer be restored"))
+	}
+	msg := restoreMessage(*deleted)
+	if !s.verifyVaultSignature(deleted.PublicKey, deleted.PluginID.String(), []byte(msg), req.Signature) {
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInvalidSignature, "invalid restore signature"))
+	}
+
+	if err := s.db.RestorePluginPolicy(ctx, policyID, restoreAfter); err != nil {
+		if errors.Is(err, interfaces.ErrPolicyNotFound) {
+			// Restored or purged concurrently
+			return codedError(c, NewCodedErrorResponse(ErrorCodePolicyNotFound, "deleted policy not found"))
+		}
+		s.logger.WithError(err).WithField("policy_id", policyID).Error("Failed to restore policy")
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInternal, "failed to restore policy"))
+	}
+
+	restored, err := s.policyService.GetPluginPolicy(ctx, policyID)
+	if err != nil {
+		s.logger.WithError(err).WithField("policy_id", policyID).Error("Failed to get restored policy")
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInternal, "failed to get restored policy"))
+	}
+	if err := s.insertPolicyEvent(ctx, types.SystemEventTypePluginPolicyRestored, types.PluginPolicyRecord{
+		PluginPolicy: *restored,
+	}); err != nil {
+		s.logger.WithError(err).WithField("policy_id", policyID).Error("Failed to record policy event")
+	}
+	s.logger.WithField("policy_id", policyID).Info("Policy restored")
+
+	return c.JSON(http.StatusOK, restored)
+}
+
+// purgeDeletedPolicies periodically removes the policies deleted longer ago than the retention
+// period. Their revisions are kept as the approval history of the vault.
+func (s *Server) purgeDeletedPolicies() {
+	ticker := time.NewTicker(policyPurgeInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx := context.Background()
+		purged, err := s.db.PurgeDeletedPluginPolicies(ctx, time.Now().UTC().Add(-s.purgeAfter()))
+		if err != nil {
+			s.logger.WithError(err).Error("Failed to purge deleted policies")
+			continue
+		}
+		for _, policy := range purged {
+			s.logger.WithField("policy_id", policy.ID).Info("Deleted policy purged")
+			if err := s.insertPolicyEvent(ctx, types.SystemEventTypePluginPolicyPurged, policy); err != nil {
+				s.logger.WithError(err).WithField("policy_id", policy.ID).Error("Failed to record policy event")
+			}
+		}
+	}
+}
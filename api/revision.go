@@ -0,0 +1,63 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/vultisig/pluginagent/storage/interfaces"
+	"github.com/vultisig/pluginagent/types"
+)
+
+type PolicyRevisionsResponse struct {
+	Revisions []types.PluginPolicyRevision `json:"revisions"`
+}
+
+// GetPolicyRevisions lists every revision of the policy the vault owner approved, oldest first.
+// Revisions are kept after the policy is deleted and purged.
+func (s *Server) GetPolicyRevisions(c echo.Context) error {
+	policyID, err := uuid.Parse(c.Param("policyId"))
+	if err != nil {
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInvalidRequest, "invalid policy ID"))
+	}
+
+	revisions, err := s.policyService.ListPolicyRevisions(c.Request().Context(), policyID)
+	if err != nil {
+		s.logger.WithError(err).WithField("policy_id", policyID).Error("Failed to list policy revisions")
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInternal, "failed to list policy revisions"))
+	}
+	// Every stored policy has at least one revision
+	if len(revisions) == 0 {
+		return codedError(c, NewCodedErrorResponse(ErrorCodePolicyNotFound, "policy not found"))
+	}
+	return c.JSON(http.StatusOK, PolicyRevisionsResponse{Revisions: revisions})
+}
+
+// DiffPolicyRevisions compares the recipes of the revisions given by the from and to query
+// parameters rule by rule.
+func (s *Server) DiffPolicyRevisions(c echo.Context) error {
+	policyID, err := uuid.Parse(c.Param("policyId"))
+	if err != nil {
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInvalidRequest, "invalid policy ID"))
+	}
+	from, err := strconv.Atoi(c.QueryParam("from"))
+	if err != nil || from < 1 {
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInvalidRequest, "from must be a revision number"))
+	}
+	to, err := strconv.Atoi(c.QueryParam("to"))
+	if err != nil || to < 1 {
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInvalidRequest, "to must be a revision number"))
+	}
+
+	diff, err := s.policyService.DiffPolicyRevisions(c.Request().Context(), policyID, from, to)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrPolicyRevisionNotFound) {
+			return codedError(c, NewCodedErrorResponse(ErrorCodeRevisionNotFound, err.Error()))
+		}
+		s.logger.WithError(err).WithField("policy_id", policyID).Error("Failed to diff policy revisions")
+		return codedError(c, NewCodedErrorResponse(ErrorCodeInternal, "failed to diff policy revisions"))
+	}
+	return c.JSON(http.StatusOK, diff)
+}
@@ -1,10 +1,14 @@
 package api
 
 import (
+	"context"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/DataDog/datadog-go/statsd"
@@ -14,47 +18,57 @@ import (
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/labstack/gommon/log"
 	"github.com/sirupsen/logrus"
-	"github.com/vultisig/plugin/service"
+	"github.com/vultisig/pluginagent/callback"
 	"github.com/vultisig/pluginagent/config"
 	"github.com/vultisig/pluginagent/policy"
+	"github.com/vultisig/pluginagent/proposal"
 	"github.com/vultisig/pluginagent/storage"
 	"github.com/vultisig/pluginagent/storage/interfaces"
 	"github.com/vultisig/pluginagent/types"
 	vv "github.com/vultisig/verifier/common/vultisig_validator"
-	"github.com/vultisig/verifier/plugin/keysign"
 	"github.com/vultisig/verifier/plugin/tasks"
 	vtypes "github.com/vultisig/verifier/types"
 	"github.com/vultisig/verifier/vault"
 	vgcommon "github.com/vultisig/vultisig-go/common"
-	vgrelay "github.com/vultisig/vultisig-go/relay"
 	vgtypes "github.com/vultisig/vultisig-go/types"
 )
 
 type Server struct {
 	cfg           config.ServerConfig
 	pluginCfg     config.PluginConfig
+	simulationCfg config.SimulationConfig
+	approvalCfg   config.ApprovalConfig
+	feeCeilings   map[string]config.FeeCeilingConfig
+	retentionCfg  config.PolicyRetentionConfig
+	callbackCfg   config.CallbackConfig
 	db            interfaces.DatabaseStorage
 	redis         *storage.RedisStorage
 	vaultStorage  vault.Storage
 	client        *asynq.Client
 	inspector     *asynq.Inspector
 	sdClient      *statsd.Client
-	policyService service.Policy
+	policyService policy.Service
+	simulator     proposal.Simulator
+	nonces        *proposal.NonceManager
 	logger        *logrus.Logger
-	signer        *keysign.Signer
 }
 
 // NewServer returns a new server.
 func NewServer(
 	cfg config.ServerConfig,
 	pluginCfg config.PluginConfig,
+	simulationCfg config.SimulationConfig,
+	approvalCfg config.ApprovalConfig,
+	feeCeilings map[string]config.FeeCeilingConfig,
+	retentionCfg config.PolicyRetentionConfig,
+	callbackCfg config.CallbackConfig,
 	db interfaces.DatabaseStorage,
 	redis *storage.RedisStorage,
 	vaultStorage vault.Storage,
 	client *asynq.Client,
 	inspector *asynq.Inspector,
-	relayClient *vgrelay.Client,
-	verifierCfg config.VerifierConfig,
+	simulator proposal.Simulator,
+	nonces *proposal.NonceManager,
 ) *Server {
 	logger := logrus.WithField("service", "plugin").Logger
 
@@ -63,22 +77,14 @@ func NewServer(
 		logger.Fatalf("Failed to initialize policy service: %v", err)
 	}
 
-	signer := keysign.NewSigner(
-		logger.WithField("pkg", "keysign.Signer").Logger,
-		relayClient,
-		[]keysign.Emitter{
-			keysign.NewVerifierEmitter(verifierCfg.URL, verifierCfg.Token),
-			keysign.NewPluginEmitter(client, tasks.TypeKeySignDKLS, tasks.QUEUE_NAME),
-		},
-		[]string{
-			verifierCfg.Prefix,
-			"vultisig-tester-ae1d",
-		},
-	)
-
 	return &Server{
 		cfg:           cfg,
 		pluginCfg:     pluginCfg,
+		simulationCfg: simulationCfg,
+		approvalCfg:   approvalCfg,
+		feeCeilings:   feeCeilings,
+		retentionCfg:  retentionCfg,
+		callbackCfg:   callbackCfg,
 		redis:         redis,
 		client:        client,
 		inspector:     inspector,
@@ -86,7 +92,8 @@ func NewServer(
 		db:            db,
 		logger:        logger,
 		policyService: policyService,
-		signer:        signer,
+		simulator:     simulator,
+		nonces:        nonces,
 	}
 }
 
@@ -103,13 +110,30 @@ func (s *Server) StartServer() error {
 	)
 	e.Use(middleware.RateLimiter(limiterStore))
 
-	e.Validator = &vv.VultisigValidator{Validator: validator.New()}
+	v := validator.New()
+	// Report JSON field names in validation errors
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	e.Validator = &vv.VultisigValidator{Validator: v}
 
 	e.GET("/ping", s.Ping)
 	e.GET("/events", s.GetEvents)
 	e.GET("/address/derive", s.DeriveAddress)
 
 	e.POST("/propose", s.Propose)
+	e.POST("/propose/decode", s.DecodeProposal)
+	e.GET("/propose/:id", s.GetProposal)
+	e.POST("/propose/:id/approve", s.ApproveProposal)
+	e.POST("/propose/:id/reject", s.RejectProposal)
+	e.GET("/proposals", s.ListProposals)
+
+	e.POST("/nonces/reserve", s.ReserveNonce, s.pluginAuthMiddleware)
+	e.DELETE("/nonces/reservations/:id", s.ReleaseNonce, s.pluginAuthMiddleware)
 
 	grp := e.Group("/vault")
 	grp.POST("/reshare", s.ReshareVault)
@@ -124,10 +148,18 @@ func (s *Server) StartServer() error {
 	// policy mode is always available since it is used by both verifier server and plugin server
 	pluginGroup.POST("/policy", s.CreatePluginPolicy)
 	pluginGroup.PUT("/policy", s.UpdatePluginPolicyById)
+	pluginGroup.GET("/policy/:policyId", s.GetPluginPolicyById)
+	pluginGroup.GET("/policies", s.GetAllPluginPolicies)
 	pluginGroup.GET("/recipe-specification", s.GetRecipeSpecification)
 	pluginGroup.DELETE("/policy/:policyId", s.DeletePluginPolicyById)
+	pluginGroup.POST("/policy/:policyId/restore", s.RestorePluginPolicy)
+	pluginGroup.GET("/policy/:policyId/revisions", s.GetPolicyRevisions)
+	pluginGroup.GET("/policy/:policyId/revisions/diff", s.DiffPolicyRevisions)
+	pluginGroup.GET("/policy/:policyId/spend", s.GetPolicySpendUsage)
 
 	go s.streamNewEvents()
+	go s.expireApprovals()
+	go s.purgeDeletedPolicies()
 
 	return e.Start(fmt.Sprintf(":%d", s.cfg.Port))
 }
@@ -205,16 +237,41 @@ func (s *Server) GetVault(c echo.Context) error {
 	})
 }
 
+// checkCallbackURL rejects callback URLs the agent won't call: any URL while callbacks are
+// disabled, and URLs that aren't https or resolve to non-public addresses.
+func (s *Server) checkCallbackURL(ctx context.Context, url string) error {
+	if url == "" {
+		return nil
+	}
+	if s.callbackCfg.Secret == "" {
+		return errors.New("callbacks are disabled, no callback secret is configured")
+	}
+	return callback.ValidateURL(ctx, url)
+}
+
+// SignMessagesRequest is a keysign request with the URL to call back once the keysign task
+// completes or fails. The URL is kept in the task payload for the worker.
+type SignMessagesRequest struct {
+	vtypes.KeysignRequest
+	CallbackURL string `json:"callback_url,omitempty" validate:"omitempty,url"`
+}
+
 // SignMessages is a handler to process Keysing request
 func (s *Server) SignMessages(c echo.Context) error {
 	s.logger.Debug("VERIFIER SERVER: SIGN MESSAGES")
-	var req vtypes.KeysignRequest
+	var req SignMessagesRequest
 	if err := c.Bind(&req); err != nil {
 		return fmt.Errorf("fail to parse request, err: %w", err)
 	}
 	if err := req.IsValid(); err != nil {
 		return fmt.Errorf("invalid request, err: %w", err)
 	}
+	if err := c.Validate(&req); err != nil {
+		return fmt.Errorf("invalid request, err: %w", err)
+	}
+	if err := s.checkCallbackURL(c.Request().Context(), req.CallbackURL); err != nil {
+		return fmt.Errorf("invalid request, err: %w", err)
+	}
 	if !s.isValidHash(req.PublicKey) {
 		return c.NoContent(http.StatusBadRequest)
 	}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"github.com/vultisig/mobile-tss-lib/tss"
+)
+
+// KeyedSignatures marshals signatures as a JSON object keyed by keysign message hash, with the
+// keys in keysign message order rather than the sorted order of a map. Signatures missing from
+// Order follow, sorted by hash.
+type KeyedSignatures struct {
+	Order      []string
+	Signatures map[string]tss.KeysignResponse
+}
+
+func (k KeyedSignatures) MarshalJSON() ([]byte, error) {
+	keys := make([]string, 0, len(k.Signatures))
+	seen := make(map[string]bool, len(k.Signatures))
+	for _, hash := range k.Order {
+		if _, ok := k.Signatures[hash]; ok && !seen[hash] {
+			seen[hash] = true
+			keys = append(keys, hash)
+		}
+	}
+	var rest []string
+	for hash := range k.Signatures {
+		if !seen[hash] {
+			rest = append(rest, hash)
+		}
+	}
+	sort.Strings(rest)
+	keys = append(keys, rest...)
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, hash := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(hash)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(k.Signatures[hash])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
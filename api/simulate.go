@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/vultisig/pluginagent/proposal"
+	vtypes "github.com/vultisig/verifier/types"
+)
+
+type RevertErrorDetails struct {
+	Reason string `json:"reason,omitempty"`
+	Data   string `json:"data,omitempty"`
+}
+
+// simulateProposal runs EVM transactions of plugins with simulation enabled against the chain
+// as the vault, other transactions are not simulated. A nil response means the transaction can
+// be signed.
+func (s *Server) simulateProposal(ctx context.Context, policy vtypes.PluginPolicy, tx proposal.Tx) *ErrorResponse {
+	evmTx, ok := tx.(*proposal.EvmTx)
+	if !ok || s.simulator == nil || !s.simulationCfg.EnabledFor(policy.PluginID.String()) {
+		return nil
+	}
+
+	from, resp := s.vaultAddress(policy, evmTx.Chain)
+	if resp != nil {
+		return resp
+	}
+
+	result, err := s.simulator.Simulate(ctx, ecommon.HexToAddress(from), evmTx)
+	if err != nil {
+		s.logger.WithError(err).WithField("policy_id", policy.ID).Error("Transaction simulation failed")
+		var revert *proposal.RevertError
+		switch {
+		case errors.As(err, &revert):
+			resp := NewCodedErrorResponse(ErrorCodeTransactionReverted, err.Error())
+			details := RevertErrorDetails{Reason: revert.Reason}
+			if len(revert.Data) > 0 {
+				details.Data = hexutil.Encode(revert.Data)
+			}
+			resp.Details = details
+			return &resp
+		case errors.Is(err, proposal.ErrGasLimitTooLow):
+			resp := NewCodedErrorResponse(ErrorCodeGasLimitTooLow, err.Error())
+			return &resp
+		default:
+			resp := NewCodedErrorResponse(ErrorCodeSimulationFailed, fmt.Sprintf("failed to simulate transaction: %v", err))
+			return &resp
+		}
+	}
+
+	s.logger.WithField("policy_id", policy.ID).
+		WithField("gas_estimate", result.GasEstimate).
+		Info("Transaction simulated")
+	return nil
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/vultisig/pluginagent/policy"
+	"github.com/vultisig/pluginagent/storage/interfaces"
+)
+
+// SpendUsageResponse reports a spend limit of a policy rule. Amounts are decimal strings in the
+// token base unit.
+type SpendUsageResponse struct {
+	RuleID      string    `json:"rule_id"`
+	Network     string    `json:"network"`
+	Token       string    `json:"token"`
+	Parameter   string    `json:"parameter"`
+	Period      string    `json:"period"`
+	Max         string    `json:"max"`
+	Used        string    `json:"used"`
+	Remaining   string    `json:"remaining"`
+	WindowStart time.Time `json:"window_start"`
+}
+
+func toSpendUsageResponse(usage policy.SpendUsage) SpendUsageResponse {
+	return SpendUsageResponse{
+		RuleID:      usage.RuleID,
+		Network:     usage.Chain.String(),
+		Token:       usage.Token,
+		Parameter:   usage.Parameter,
+		Period:      usage.Period,
+		Max:         usage.Max.String(),
+		Used:        usage.Used.String(),
+		Remaining:   usage.Remaining.String(),
+		WindowStart: usage.WindowStart,
+	}
+}
+
+// GetPolicySpendUsage reports the current usage of every spend limit of the policy, i.e. every
+// max constraint with a period.
+func (s *Server) GetPolicySpendUsage(c echo.Context) error {
+	policyID, err := uuid.Parse(c.Param("policyId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, NewErrorResponse("invalid policy ID"))
+	}
+
+	pluginPolicy, err := s.policyService.GetPluginPolicy(c.Request().Context(), policyID)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrPolicyNotFound) {
+			return c.JSON(http.StatusNotFound, NewErrorResponse("policy not found"))
+		}
+		s.logger.WithError(err).WithField("policy_id", policyID).Error("Failed to get plugin policy")
+		return c.JSON(http.StatusInternalServerError, NewErrorResponse("failed to get policy"))
+	}
+
+	usages, err := s.policyService.GetSpendUsage(c.Request().Context(), *pluginPolicy)
+	if err != nil {
+		s.logger.WithError(err).WithField("policy_id", policyID).Error("Failed to get spend usage")
+		return c.JSON(http.StatusInternalServerError, NewErrorResponse("failed to get spend usage"))
+	}
+
+	resp := make([]SpendUsageResponse, 0, len(usages))
+	for _, usage := range usages {
+		resp = append(resp, toSpendUsageResponse(usage))
+	}
+	return c.JSON(http.StatusOK, resp)
+}
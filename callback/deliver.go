@@ -0,0 +1,254 @@
+package callback
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/sirupsen/logrus"
+	"github.com/vultisig/mobile-tss-lib/tss"
+	"github.com/vultisig/verifier/plugin/tasks"
+
+	"github.com/vultisig/pluginagent/config"
+	"github.com/vultisig/pluginagent/storage/interfaces"
+	"github.com/vultisig/pluginagent/types"
+)
+
+const (
+	// SignatureHeader carries "sha256=" followed by the hex HMAC-SHA256 of the timestamp, a dot and
+	// the body, keyed with the callback secret.
+	SignatureHeader = "X-Agent-Signature"
+	TimestampHeader = "X-Agent-Timestamp"
+	// DeliveryHeader is the same for every attempt of a delivery, so receivers can deduplicate.
+	DeliveryHeader = "X-Agent-Delivery"
+)
+
+const (
+	EventProposalSigned   = "proposal.signed"
+	EventProposalFailed   = "proposal.failed"
+	EventKeysignCompleted = "keysign.completed"
+	EventKeysignFailed    = "keysign.failed"
+)
+
+// Notification is the body of a callback.
+type Notification struct {
+	Event    string          `json:"event"`
+	Proposal *ProposalResult `json:"proposal,omitempty"`
+	Keysign  *KeysignResult  `json:"keysign,omitempty"`
+}
+
+type ProposalResult struct {
+	ID              uuid.UUID                      `json:"id"`
+	PolicyID        uuid.UUID                      `json:"policy_id"`
+	Status          types.ProposalStatus           `json:"status"`
+	Signatures      map[string]tss.KeysignResponse `json:"signatures,omitempty"`
+	SignedTxHex     *string                        `json:"signed_tx_hex,omitempty"`
+	TxHash          *string                        `json:"tx_hash,omitempty"`
+	BroadcastTxHash *string                        `json:"broadcast_tx_hash,omitempty"`
+	BroadcastError  *string                        `json:"broadcast_error,omitempty"`
+	Batch           []types.ProposalTx             `json:"batch,omitempty"`
+	Error           *string                        `json:"error,omitempty"`
+}
+
+type KeysignResult struct {
+	TaskID string          `json:"task_id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// Deliverer handles TypeCallbackDeliver tasks. A delivery fails unless the receiver answers with
+// a 2xx status, every attempt is logged. URLs given with a request are only called on public
+// addresses.
+type Deliverer struct {
+	db              interfaces.DatabaseStorage
+	inspector       *asynq.Inspector
+	httpClient      *http.Client
+	requestedClient *http.Client
+	secret          string
+	logger          *logrus.Logger
+}
+
+func NewDeliverer(
+	db interfaces.DatabaseStorage,
+	inspector *asynq.Inspector,
+	cfg config.CallbackConfig,
+	logger *logrus.Logger,
+) *Deliverer {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{
+		Timeout: timeout,
+		Control: publicOnlyControl,
+	}).DialContext
+	return &Deliverer{
+		db:         db,
+		inspector:  inspector,
+		httpClient: &http.Client{Timeout: timeout},
+		requestedClient: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+			// A redirect could point anywhere, receivers have to answer on the URL they gave
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		secret: cfg.Secret,
+		logger: logger.WithField("pkg", "callback").Logger,
+	}
+}
+
+// HandleDeliverTask is the asynq handler for TypeCallbackDeliver tasks.
+func (d *Deliverer) HandleDeliverTask(ctx context.Context, task *asynq.Task) error {
+	var payload TaskPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal task payload: %v: %w", err, asynq.SkipRetry)
+	}
+
+	deliveryID, _ := asynq.GetTaskID(ctx)
+	attempt, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+	logger := d.logger.WithField("delivery_id", deliveryID).
+		WithField("url", payload.URL).
+		WithField("attempt", attempt+1).
+		WithField("max_attempts", maxRetry+1)
+
+	if d.secret == "" {
+		logger.Error("callback secret is not configured, dropping callback")
+		return fmt.Errorf("callback secret is not configured: %w", asynq.SkipRetry)
+	}
+
+	notification, err := d.notification(ctx, payload)
+	if err != nil {
+		logger.WithError(err).Warn("callback not ready for delivery")
+		return err
+	}
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %v: %w", err, asynq.SkipRetry)
+	}
+
+	start := time.Now()
+	status, err := d.post(ctx, payload, deliveryID, body)
+	logger = logger.WithField("event", notification.Event).
+		WithField("duration_ms", time.Since(start).Milliseconds())
+	if err != nil {
+		logger.WithError(err).Warn("callback delivery failed")
+		return err
+	}
+	logger.WithField("status", status).Info("callback delivered")
+	return nil
+}
+
+func (d *Deliverer) notification(ctx context.Context, payload TaskPayload) (*Notification, error) {
+	switch payload.Kind {
+	case KindProposal:
+		p, err := d.db.GetProposal(ctx, payload.ProposalID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get proposal: %w", err)
+		}
+		event := EventProposalFailed
+		if p.Status == types.ProposalStatusSigned {
+			event = EventProposalSigned
+		}
+		return &Notification{
+			Event: event,
+			Proposal: &ProposalResult{
+				ID:              p.ID,
+				PolicyID:        p.PolicyID,
+				Status:          p.Status,
+				Signatures:      p.Signatures,
+				SignedTxHex:     p.SignedTxHex,
+				TxHash:          p.TxHash,
+				BroadcastTxHash: p.BroadcastTxHash,
+				BroadcastError:  p.BroadcastError,
+				Batch:           p.Batch,
+				Error:           p.Error,
+			},
+		}, nil
+
+	case KindKeysign:
+		result := &KeysignResult{TaskID: payload.TaskID}
+		if payload.Error != "" {
+			result.Error = payload.Error
+			return &Notification{Event: EventKeysignFailed, Keysign: result}, nil
+		}
+		// The callback is queued by the keysign handler, so the task may not be marked completed yet
+		info, err := d.inspector.GetTaskInfo(tasks.QUEUE_NAME, payload.TaskID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get keysign task: %w", err)
+		}
+		switch info.State {
+		case asynq.TaskStateCompleted:
+			result.Result = info.Result
+			return &Notification{Event: EventKeysignCompleted, Keysign: result}, nil
+		case asynq.TaskStateArchived:
+			result.Error = info.LastErr
+			return &Notification{Event: EventKeysignFailed, Keysign: result}, nil
+		default:
+			return nil, fmt.Errorf("keysign task is %s", info.State.String())
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown callback kind %q: %w", payload.Kind, asynq.SkipRetry)
+	}
+}
+
+func (d *Deliverer) post(ctx context.Context, payload TaskPayload, deliveryID string, body []byte) (int, error) {
+	client := d.httpClient
+	if payload.Requested {
+		if !strings.HasPrefix(payload.URL, "https://") {
+			return 0, fmt.Errorf("requested callback url must be https: %w", asynq.SkipRetry)
+		}
+		client = d.requestedClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, payload.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %v: %w", err, asynq.SkipRetry)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(DeliveryHeader, deliveryID)
+	req.Header.Set(SignatureHeader, "sha256="+Sign(d.secret, timestamp, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to post callback: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("callback answered with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the hex HMAC-SHA256 of the timestamp and body, the way receivers verify
+// SignatureHeader.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,145 @@
+package callback
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/sirupsen/logrus"
+
+	"github.com/vultisig/pluginagent/config"
+	"github.com/vultisig/pluginagent/types"
+)
+
+const (
+	QUEUE_NAME          = "callback_queue"
+	TypeCallbackDeliver = "callback:deliver"
+
+	defaultMaxRetry = 8
+	defaultTimeout  = 10 * time.Second
+)
+
+type Kind string
+
+const (
+	KindProposal Kind = "proposal"
+	KindKeysign  Kind = "keysign"
+)
+
+// TaskPayload is the payload of a TypeCallbackDeliver task. The notification is built when it is
+// delivered, from the proposal or the keysign task result. Requested marks URLs given with the
+// request rather than configured, which are only delivered to public addresses.
+type TaskPayload struct {
+	Kind       Kind      `json:"kind"`
+	URL        string    `json:"url"`
+	Requested  bool      `json:"requested,omitempty"`
+	ProposalID uuid.UUID `json:"proposal_id,omitempty"`
+	TaskID     string    `json:"task_id,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Notifier queues completion callbacks. Deliveries are retried by asynq with its exponential
+// backoff.
+type Notifier struct {
+	client *asynq.Client
+	cfg    config.CallbackConfig
+	logger *logrus.Logger
+}
+
+func NewNotifier(client *asynq.Client, cfg config.CallbackConfig, logger *logrus.Logger) *Notifier {
+	return &Notifier{
+		client: client,
+		cfg:    cfg,
+		logger: logger.WithField("pkg", "callback").Logger,
+	}
+}
+
+// target returns the URL a request asked to be called back on, or the URL configured for the
+// plugin. Callbacks are disabled without a secret, since receivers couldn't verify them.
+func (n *Notifier) target(requested string, pluginID string) (string, bool) {
+	if n.cfg.Secret == "" {
+		return "", false
+	}
+	if requested != "" {
+		return requested, true
+	}
+	return n.cfg.URLs[pluginID], false
+}
+
+// NotifyProposal queues the callback of a proposal whose signing completed or failed.
+func (n *Notifier) NotifyProposal(ctx context.Context, proposal types.Proposal, pluginID string) {
+	requested := ""
+	if proposal.CallbackURL != nil {
+		requested = *proposal.CallbackURL
+	}
+	url, isRequested := n.target(requested, pluginID)
+	if url == "" {
+		return
+	}
+
+	n.enqueue(ctx, "proposal:"+proposal.ID.String(), TaskPayload{
+		Kind:       KindProposal,
+		URL:        url,
+		Requested:  isRequested,
+		ProposalID: proposal.ID,
+	})
+}
+
+// NotifyKeysign queues the callback of a keysign task, to the URL the keysign request asked for or
+// the one configured for the plugin. taskErr is the error it failed with.
+func (n *Notifier) NotifyKeysign(ctx context.Context, requested string, pluginID string, taskID string, taskErr error) {
+	url, isRequested := n.target(requested, pluginID)
+	if url == "" {
+		return
+	}
+
+	payload := TaskPayload{
+		Kind:      KindKeysign,
+		URL:       url,
+		Requested: isRequested,
+		TaskID:    taskID,
+	}
+	if taskErr != nil {
+		payload.Error = taskErr.Error()
+	}
+	n.enqueue(ctx, "keysign:"+taskID, payload)
+}
+
+func (n *Notifier) enqueue(ctx context.Context, taskID string, payload TaskPayload) {
+	logger := n.logger.WithField("callback_task_id", taskID).WithField("url", payload.URL)
+
+	buf, err := json.Marshal(payload)
+	if err != nil {
+		logger.WithError(err).Error("failed to marshal callback task")
+		return
+	}
+
+	maxRetry := n.cfg.MaxRetry
+	if maxRetry <= 0 {
+		maxRetry = defaultMaxRetry
+	}
+	timeout := n.cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	_, err = n.client.EnqueueContext(ctx,
+		asynq.NewTask(TypeCallbackDeliver, buf),
+		asynq.TaskID(taskID),
+		asynq.MaxRetry(maxRetry),
+		asynq.Timeout(timeout),
+		asynq.Retention(24*time.Hour),
+		asynq.Queue(QUEUE_NAME))
+	if err != nil {
+		if errors.Is(err, asynq.ErrTaskIDConflict) {
+			logger.Info("callback already queued")
+			return
+		}
+		logger.WithError(err).Error("failed to enqueue callback")
+		return
+	}
+	logger.Info("callback queued")
+}
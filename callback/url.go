@@ -0,0 +1,66 @@
+package callback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"syscall"
+)
+
+// sharedAddressSpace is the carrier-grade NAT range, which net.IP doesn't count as private.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// ValidateURL checks a callback URL given with a request. It must be https and its host must only
+// resolve to public addresses, so requests can't make the agent call internal services. URLs of
+// the callback config are trusted and not checked.
+func ValidateURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid callback url: %w", err)
+	}
+	if u.Scheme != "https" {
+		return errors.New("callback url must be https")
+	}
+	if u.Hostname() == "" {
+		return errors.New("callback url has no host")
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return fmt.Errorf("failed to resolve callback host: %w", err)
+	}
+	for _, addr := range addrs {
+		if !isPublicIP(addr.IP) {
+			return fmt.Errorf("callback host resolves to non-public address %s", addr.IP.String())
+		}
+	}
+	return nil
+}
+
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() &&
+		!ip.IsPrivate() &&
+		!ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() &&
+		!sharedAddressSpace.Contains(ip)
+}
+
+// publicOnlyControl refuses connections to non-public addresses. It runs on the resolved address
+// of every connection, so a requested URL can't be rebound to an internal host after it was
+// validated.
+func publicOnlyControl(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("refusing to call back non-public address %s", host)
+	}
+	return nil
+}
@@ -8,9 +8,9 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/vultisig/pluginagent/api"
 	"github.com/vultisig/pluginagent/config"
+	"github.com/vultisig/pluginagent/proposal"
 	"github.com/vultisig/pluginagent/storage"
 	"github.com/vultisig/verifier/vault"
-	vgrelay "github.com/vultisig/vultisig-go/relay"
 )
 
 func main() {
@@ -60,18 +60,31 @@ func main() {
 		logger.Fatalf("Failed to connect to database: %v", err)
 	}
 
-	relayClient := vgrelay.NewRelayClient(cfg.VaultService.Relay.Server)
+	simulator, err := proposal.NewEvmSimulator(cfg.Rpc.Endpoints)
+	if err != nil {
+		logger.Fatalf("Failed to initialize simulator: %v", err)
+	}
+
+	nonces, err := proposal.NewNonceManager(db, cfg.Rpc.Endpoints, cfg.Nonce.ReservationTTL)
+	if err != nil {
+		logger.Fatalf("Failed to initialize nonce manager: %v", err)
+	}
 
 	server := api.NewServer(
 		cfg.Server,
 		cfg.Plugin,
+		cfg.Simulation,
+		cfg.Approval,
+		cfg.FeeCeilings,
+		cfg.Retention,
+		cfg.Callback,
 		db,
 		redisStorage,
 		vaultStorage,
 		client,
 		inspector,
-		relayClient,
-		cfg.Verifier,
+		simulator,
+		nonces,
 	)
 
 	if err := server.StartServer(); err != nil {
@@ -3,19 +3,25 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 
 	"github.com/hibiken/asynq"
 	"github.com/sirupsen/logrus"
 
+	"github.com/vultisig/pluginagent/callback"
 	"github.com/vultisig/pluginagent/config"
+	"github.com/vultisig/pluginagent/policy"
+	"github.com/vultisig/pluginagent/proposal"
 	"github.com/vultisig/pluginagent/storage"
 	"github.com/vultisig/pluginagent/storage/interfaces"
 	"github.com/vultisig/pluginagent/types"
+	"github.com/vultisig/verifier/plugin/keysign"
 	"github.com/vultisig/verifier/plugin/tasks"
 	vtypes "github.com/vultisig/verifier/types"
 	"github.com/vultisig/verifier/vault"
+	vgrelay "github.com/vultisig/vultisig-go/relay"
 )
 
 func main() {
@@ -47,13 +53,30 @@ func main() {
 		panic(fmt.Sprintf("failed to initialize vault storage: %v", err))
 	}
 
+	inspector := asynq.NewInspector(redisOptions)
+	notifier := callback.NewNotifier(client, cfg.Callback, logger)
+	deliverer := callback.NewDeliverer(db, inspector, cfg.Callback, logger)
+
 	srv := asynq.NewServer(
 		redisOptions,
 		asynq.Config{
 			Logger:      logger,
 			Concurrency: 10,
 			Queues: map[string]int{
-				tasks.QUEUE_NAME: 10,
+				tasks.QUEUE_NAME:    10,
+				callback.QUEUE_NAME: 2,
+			},
+		},
+	)
+	// Proposal signing waits for the plugin keysign party, a TypeKeySignDKLS task of srv. Signing
+	// runs on its own pool so it never holds the slots that party needs.
+	proposalSrv := asynq.NewServer(
+		redisOptions,
+		asynq.Config{
+			Logger:      logger,
+			Concurrency: 5,
+			Queues: map[string]int{
+				proposal.QUEUE_NAME: 1,
 			},
 		},
 	)
@@ -70,19 +93,69 @@ func main() {
 		panic(fmt.Sprintf("failed to initialize vault management service: %v", err))
 	}
 
+	signer := keysign.NewSigner(
+		logger.WithField("pkg", "keysign.Signer").Logger,
+		vgrelay.NewRelayClient(cfg.VaultService.Relay.Server),
+		[]keysign.Emitter{
+			keysign.NewVerifierEmitter(cfg.Verifier.URL, cfg.Verifier.Token),
+			keysign.NewPluginEmitter(client, tasks.TypeKeySignDKLS, tasks.QUEUE_NAME),
+		},
+		[]string{
+			cfg.Verifier.Prefix,
+			"vultisig-tester-ae1d",
+		},
+	)
+
+	broadcaster, err := proposal.NewEvmBroadcaster(cfg.Rpc.Endpoints)
+	if err != nil {
+		panic(fmt.Sprintf("failed to initialize broadcaster: %v", err))
+	}
+
+	policyService, err := policy.NewPolicyService(db, logger.WithField("service", "policy").Logger)
+	if err != nil {
+		panic(fmt.Sprintf("failed to initialize policy service: %v", err))
+	}
+
+	proposalService := proposal.NewService(
+		db,
+		signer,
+		broadcaster,
+		policyService,
+		vaultStorage,
+		cfg.VaultService.EncryptionSecret,
+		notifier,
+		logger,
+	)
+
 	mux := asynq.NewServeMux()
-	mux.HandleFunc(tasks.TypeKeyGenerationDKLS, resultWriter(db, vaultMgmService.HandleKeyGenerationDKLS))
-	mux.HandleFunc(tasks.TypeKeySignDKLS, resultWriter(db, vaultMgmService.HandleKeySignDKLS))
-	mux.HandleFunc(tasks.TypeReshareDKLS, resultWriter(db, vaultMgmService.HandleReshareDKLS))
+	mux.HandleFunc(tasks.TypeKeyGenerationDKLS, resultWriter(db, notifier, vaultMgmService.HandleKeyGenerationDKLS))
+	mux.HandleFunc(tasks.TypeKeySignDKLS, resultWriter(db, notifier, vaultMgmService.HandleKeySignDKLS))
+	mux.HandleFunc(tasks.TypeReshareDKLS, resultWriter(db, notifier, vaultMgmService.HandleReshareDKLS))
+	mux.HandleFunc(callback.TypeCallbackDeliver, deliverer.HandleDeliverTask)
 
-	if err := srv.Run(mux); err != nil {
+	proposalMux := asynq.NewServeMux()
+	proposalMux.HandleFunc(proposal.TypeProposalSign, proposalService.HandleSignTask)
+
+	if err := proposalSrv.Start(proposalMux); err != nil {
+		panic(fmt.Errorf("could not start proposal server: %w", err))
+	}
+	reaperCtx, stopReaper := context.WithCancel(context.Background())
+	go proposalService.FailStaleProposals(reaperCtx)
+
+	err = srv.Run(mux)
+	stopReaper()
+	proposalSrv.Shutdown()
+	if err != nil {
 		panic(fmt.Errorf("could not run server: %w", err))
 	}
 }
 
-func resultWriter(db interfaces.DatabaseStorage, handler asynq.HandlerFunc) asynq.HandlerFunc {
+func resultWriter(db interfaces.DatabaseStorage, notifier *callback.Notifier, handler asynq.HandlerFunc) asynq.HandlerFunc {
 	return func(ctx context.Context, task *asynq.Task) error {
 		err := handler(ctx, task)
+		if task.Type() == tasks.TypeKeySignDKLS {
+			notifyKeysign(ctx, notifier, task, err)
+		}
 		if err != nil {
 			return err
 		}
@@ -111,3 +184,25 @@ func resultWriter(db interfaces.DatabaseStorage, handler asynq.HandlerFunc) asyn
 		return nil
 	}
 }
+
+// notifyKeysign queues the callback of a keysign task once it completed or won't be retried.
+func notifyKeysign(ctx context.Context, notifier *callback.Notifier, task *asynq.Task, taskErr error) {
+	if taskErr != nil {
+		retried, _ := asynq.GetRetryCount(ctx)
+		maxRetry, _ := asynq.GetMaxRetry(ctx)
+		if retried < maxRetry && !errors.Is(taskErr, asynq.SkipRetry) {
+			return
+		}
+	}
+
+	var taskData struct {
+		PluginID    string `json:"plugin_id"`
+		CallbackURL string `json:"callback_url"`
+	}
+	if err := json.Unmarshal(task.Payload(), &taskData); err != nil {
+		fmt.Printf("failed to unmarshal keysign task: %v", err)
+		return
+	}
+	taskID, _ := asynq.GetTaskID(ctx)
+	notifier.NotifyKeysign(ctx, taskData.CallbackURL, taskData.PluginID, taskID, taskErr)
+}
@@ -1,18 +1,28 @@
 package config
 
 import (
+	"errors"
+	"time"
+
 	"github.com/spf13/viper"
 	"github.com/vultisig/verifier/vault_config"
 )
 
 type Config struct {
-	Redis        RedisConfig               `mapstructure:"redis" json:"redis"`
-	VaultService vault_config.Config       `mapstructure:"vault_service" json:"vault_service,omitempty"`
-	BlockStorage vault_config.BlockStorage `mapstructure:"block_storage" json:"block_storage,omitempty"`
-	Server       ServerConfig              `mapstructure:"server" json:"server,omitempty"`
-	Database     DatabaseConfig            `mapstructure:"database" json:"database,omitempty"`
-	Plugin       PluginConfig              `mapstructure:"plugin" json:"plugin,omitempty"`
-	Verifier     VerifierConfig            `mapstructure:"verifier" json:"verifier,omitempty"`
+	Redis        RedisConfig                 `mapstructure:"redis" json:"redis"`
+	VaultService vault_config.Config         `mapstructure:"vault_service" json:"vault_service,omitempty"`
+	BlockStorage vault_config.BlockStorage   `mapstructure:"block_storage" json:"block_storage,omitempty"`
+	Server       ServerConfig                `mapstructure:"server" json:"server,omitempty"`
+	Database     DatabaseConfig              `mapstructure:"database" json:"database,omitempty"`
+	Plugin       PluginConfig                `mapstructure:"plugin" json:"plugin,omitempty"`
+	Verifier     VerifierConfig              `mapstructure:"verifier" json:"verifier,omitempty"`
+	Rpc          RpcConfig                   `mapstructure:"rpc" json:"rpc,omitempty"`
+	Simulation   SimulationConfig            `mapstructure:"simulation" json:"simulation,omitempty"`
+	Approval     ApprovalConfig              `mapstructure:"approval" json:"approval,omitempty"`
+	Nonce        NonceConfig                 `mapstructure:"nonce" json:"nonce,omitempty"`
+	FeeCeilings  map[string]FeeCeilingConfig `mapstructure:"fee_ceilings" json:"fee_ceilings,omitempty"`
+	Callback     CallbackConfig              `mapstructure:"callback" json:"callback,omitempty"`
+	Retention    PolicyRetentionConfig       `mapstructure:"policy_retention" json:"policy_retention,omitempty"`
 }
 
 type VerifierConfig struct {
@@ -21,9 +31,79 @@ type VerifierConfig struct {
 	Prefix string `mapstructure:"prefix" json:"prefix,omitempty"`
 }
 
+// PluginConfig describes the plugin the agent serves. The plugin authenticates its nonce requests
+// with ApiKey as a bearer token, they are rejected while no key is configured.
 type PluginConfig struct {
 	PluginID                    string `mapstructure:"plugin_id" json:"plugin_id,omitempty"`
 	RecipeSpecificationFilePath string `mapstructure:"recipe_specification_file_path" json:"recipe_specification_file_path,omitempty"`
+	ApiKey                      string `mapstructure:"api_key" json:"api_key,omitempty"`
+}
+
+// RpcConfig maps chain names (e.g. "ethereum", "arbitrum") to their JSON-RPC endpoints.
+type RpcConfig struct {
+	Endpoints map[string]string `mapstructure:"endpoints" json:"endpoints,omitempty"`
+}
+
+// SimulationConfig switches pre-sign simulation of EVM transactions. Enabled is the default,
+// Plugins overrides it per plugin ID.
+type SimulationConfig struct {
+	Enabled bool            `mapstructure:"enabled" json:"enabled,omitempty"`
+	Plugins map[string]bool `mapstructure:"plugins" json:"plugins,omitempty"`
+}
+
+func (c SimulationConfig) EnabledFor(pluginID string) bool {
+	if enabled, ok := c.Plugins[pluginID]; ok {
+		return enabled
+	}
+	return c.Enabled
+}
+
+// ApprovalConfig holds back proposals that move more than a threshold until the vault owner
+// approves them. Thresholds maps chain names to token thresholds in base units, with tokens named
+// like in the spend ledger: the lowercase native symbol (e.g. "eth") or contract address. Only EVM
+// and UTXO transactions can be valued, proposals on other chains with thresholds are rejected.
+type ApprovalConfig struct {
+	Thresholds map[string]map[string]string `mapstructure:"thresholds" json:"thresholds,omitempty"`
+	Expiry     time.Duration                `mapstructure:"expiry" json:"expiry,omitempty"`
+}
+
+// NonceConfig sets how long a nonce reservation holds its nonce before it is handed out again.
+type NonceConfig struct {
+	ReservationTTL time.Duration `mapstructure:"reservation_ttl" json:"reservation_ttl,omitempty"`
+}
+
+// FeeCeilingConfig caps the fees of the transactions of a chain, keyed by chain name in
+// Config.FeeCeilings. Fees are in the native base unit, zero or empty fields are not capped.
+// Policies can narrow the ceilings through fee_ceilings in their recipe configuration, which the
+// agent validates itself, so recipe specifications don't have to declare it.
+type FeeCeilingConfig struct {
+	MaxGasLimit  uint64 `mapstructure:"max_gas_limit" json:"max_gas_limit,omitempty"`
+	MaxFeePerGas string `mapstructure:"max_fee_per_gas" json:"max_fee_per_gas,omitempty"`
+	MaxTotalFee  string `mapstructure:"max_total_fee" json:"max_total_fee,omitempty"`
+}
+
+// CallbackConfig configures the completion callbacks of proposals and keysign tasks. URLs maps
+// plugin IDs to the URL called when a request names none. Deliveries are signed with Secret and
+// retried up to MaxRetry times. Callbacks are disabled while no secret is configured.
+type CallbackConfig struct {
+	Secret   string            `mapstructure:"secret" json:"secret,omitempty"`
+	URLs     map[string]string `mapstructure:"urls" json:"urls,omitempty"`
+	Timeout  time.Duration     `mapstructure:"timeout" json:"timeout,omitempty"`
+	MaxRetry int               `mapstructure:"max_retry" json:"max_retry,omitempty"`
+}
+
+func (c CallbackConfig) Validate() error {
+	if c.Secret == "" && len(c.URLs) > 0 {
+		return errors.New("callback.secret is required when callback urls are configured")
+	}
+	return nil
+}
+
+// PolicyRetentionConfig sets how long deleted policies can be restored by their owner and when
+// they are purged for good.
+type PolicyRetentionConfig struct {
+	RestoreWindow time.Duration `mapstructure:"restore_window" json:"restore_window,omitempty"`
+	PurgeAfter    time.Duration `mapstructure:"purge_after" json:"purge_after,omitempty"`
 }
 
 type DatabaseConfig struct {
@@ -45,6 +125,11 @@ type RedisConfig struct {
 	DB       int    `mapstructure:"db" json:"db"`
 }
 
+// Validate rejects settings the agent can't run with safely.
+func (c *Config) Validate() error {
+	return c.Callback.Validate()
+}
+
 func LoadServerConfig() (*Config, error) {
 	cfg := &Config{}
 
@@ -59,6 +144,9 @@ func LoadServerConfig() (*Config, error) {
 	if err := viper.Unmarshal(cfg); err != nil {
 		return nil, err
 	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
 
 	return cfg, nil
 }
@@ -77,6 +165,9 @@ func LoadWorkerConfig() (*Config, error) {
 	if err := viper.Unmarshal(cfg); err != nil {
 		return nil, err
 	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
 
 	return cfg, nil
 }
@@ -0,0 +1,102 @@
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	rtypes "github.com/vultisig/recipes/types"
+)
+
+// parseIndexedConstraints groups constraints named <field>_<index> by index, the way the recipes
+// BTC engine addresses outputs. Only the given fields are accepted.
+func parseIndexedConstraints(
+	rule *rtypes.Rule,
+	fields ...string,
+) (map[int]map[string]*rtypes.ParameterConstraint, error) {
+	constraints := make(map[int]map[string]*rtypes.ParameterConstraint)
+	for _, constraint := range rule.GetParameterConstraints() {
+		name := constraint.GetParameterName()
+
+		field := ""
+		for _, f := range fields {
+			if strings.HasPrefix(name, f+"_") {
+				field = f
+				break
+			}
+		}
+		if field == "" {
+			return nil, fmt.Errorf("unsupported constraint parameter name (only %s supported): %s",
+				strings.Join(fields, "_*, ")+"_*", name)
+		}
+
+		index, err := strconv.Atoi(strings.TrimPrefix(name, field+"_"))
+		if err != nil || index < 0 {
+			return nil, fmt.Errorf("invalid constraint name: %s", name)
+		}
+		if constraints[index] == nil {
+			constraints[index] = make(map[string]*rtypes.ParameterConstraint)
+		}
+		constraints[index][field] = constraint
+	}
+	return constraints, nil
+}
+
+// validateIndexedConstraints matches the transaction elements (instructions, messages) by
+// position. Every element must be described by the rule and constrain every field.
+func validateIndexedConstraints(
+	element string,
+	constraints map[int]map[string]*rtypes.ParameterConstraint,
+	fields []string,
+	values []map[string]string,
+) error {
+	if len(constraints) != len(values) {
+		return fmt.Errorf("%s count mismatch: rule has %d %ss, tx has %d %ss",
+			element, len(constraints), element, len(values), element)
+	}
+
+	for i, actual := range values {
+		for _, field := range fields {
+			constraint, ok := constraints[i][field]
+			if !ok {
+				return fmt.Errorf("missing %s_%d constraint", field, i)
+			}
+			if err := validateStringConstraint(constraint, actual[field]); err != nil {
+				return fmt.Errorf("%s %d %s validation failed: %w", element, i, field, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateStringConstraint(constraint *rtypes.ParameterConstraint, actual string) error {
+	kind := constraint.GetConstraint().GetType()
+
+	switch kind {
+	case rtypes.ConstraintType_CONSTRAINT_TYPE_ANY:
+		return nil
+
+	case rtypes.ConstraintType_CONSTRAINT_TYPE_FIXED:
+		if constraint.GetConstraint().GetFixedValue() == actual {
+			return nil
+		}
+		return fmt.Errorf("fixed value constraint failed: expected=%v, actual=%v",
+			constraint.GetConstraint().GetFixedValue(), actual)
+
+	case rtypes.ConstraintType_CONSTRAINT_TYPE_REGEXP:
+		ok, err := regexp.MatchString(constraint.GetConstraint().GetRegexpValue(), actual)
+		if err != nil {
+			return fmt.Errorf("regexp match failed: expected=%v, actual=%v",
+				constraint.GetConstraint().GetRegexpValue(), actual)
+		}
+		if ok {
+			return nil
+		}
+		return fmt.Errorf("regexp value constraint failed: expected=%v, actual=%v",
+			constraint.GetConstraint().GetRegexpValue(), actual)
+
+	default:
+		return fmt.Errorf("unsupported constraint type: %s", kind.String())
+	}
+}
@@ -0,0 +1,44 @@
+package policy
+
+import (
+	"fmt"
+
+	"github.com/vultisig/pluginagent/proposal"
+	rtypes "github.com/vultisig/recipes/types"
+)
+
+var cosmosMessageFields = []string{"message_type", "message_data"}
+
+// cosmosEngine evaluates rules against Cosmos SDK sign docs, in the same shape as solanaEngine:
+// every message must be described by a message_type_<i> constraint on the type URL (direct) or
+// amino type (amino_json), and a message_data_<i> constraint on its value.
+type cosmosEngine struct{}
+
+func (e *cosmosEngine) Evaluate(rule *rtypes.Rule, txBytes []byte) error {
+	if rule.GetEffect() != rtypes.Effect_EFFECT_ALLOW {
+		return fmt.Errorf("only allow rules supported, got: %s", rule.GetEffect().String())
+	}
+	if rule.GetTarget() != nil {
+		return fmt.Errorf("target must be nil for Cosmos chains, got: %s", rule.GetTarget().String())
+	}
+
+	tx, err := proposal.ParseCosmosPayload(txBytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse cosmos sign doc: %w", err)
+	}
+
+	constraints, err := parseIndexedConstraints(rule, cosmosMessageFields...)
+	if err != nil {
+		return err
+	}
+
+	values := make([]map[string]string, 0, len(tx.Messages))
+	for _, msg := range tx.Messages {
+		values = append(values, map[string]string{
+			"message_type": msg.Type,
+			"message_data": msg.Data,
+		})
+	}
+
+	return validateIndexedConstraints("message", constraints, cosmosMessageFields, values)
+}
@@ -0,0 +1,89 @@
+package policy
+
+import (
+	"testing"
+
+	rtypes "github.com/vultisig/recipes/types"
+)
+
+// The direct mode MsgSend fixture of proposal/cosmos_test.go: 1000uatom from cosmos1from to
+// cosmos1to.
+const (
+	cosmosDirectPayload = `{"sign_mode":"direct","chain_id":"cosmoshub-4","sign_doc":"ClIKRwocL2Nvc21vcy5iYW5rLnYxYmV0YTEuTXNnU2VuZBInCgtjb3Ntb3MxZnJvbRIJY29zbW9zMXRvGg0KBXVhdG9tEgQxMDAwEgdmaXh0dXJlEmcKUApGCh8vY29zbW9zLmNyeXB0by5zZWNwMjU2azEuUHViS2V5EiMKIQOL+vHLaWQjprstX3NLKN3jViALj5t512bfXT4j/u7PaxIECgIIARgHEhMKDQoFdWF0b20SBDUwMDAQwJoMGgtjb3Ntb3NodWItNCAq"}`
+	cosmosMsgSendType   = "/cosmos.bank.v1beta1.MsgSend"
+	cosmosMsgSendData   = "0a0b636f736d6f733166726f6d1209636f736d6f7331746f1a0d0a057561746f6d120431303030"
+)
+
+func fixedConstraint(name, value string) *rtypes.ParameterConstraint {
+	return &rtypes.ParameterConstraint{
+		ParameterName: name,
+		Constraint: &rtypes.Constraint{
+			Type:  rtypes.ConstraintType_CONSTRAINT_TYPE_FIXED,
+			Value: &rtypes.Constraint_FixedValue{FixedValue: value},
+		},
+	}
+}
+
+func TestCosmosEngineEvaluate(t *testing.T) {
+	tests := []struct {
+		name        string
+		constraints []*rtypes.ParameterConstraint
+		wantErr     bool
+	}{
+		{
+			name: "matching message",
+			constraints: []*rtypes.ParameterConstraint{
+				fixedConstraint("message_type_0", cosmosMsgSendType),
+				fixedConstraint("message_data_0", cosmosMsgSendData),
+			},
+		},
+		{
+			name: "other message type",
+			constraints: []*rtypes.ParameterConstraint{
+				fixedConstraint("message_type_0", "/cosmos.staking.v1beta1.MsgDelegate"),
+				fixedConstraint("message_data_0", cosmosMsgSendData),
+			},
+			wantErr: true,
+		},
+		{
+			name: "other message data",
+			constraints: []*rtypes.ParameterConstraint{
+				fixedConstraint("message_type_0", cosmosMsgSendType),
+				fixedConstraint("message_data_0", "00"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing data constraint",
+			constraints: []*rtypes.ParameterConstraint{
+				fixedConstraint("message_type_0", cosmosMsgSendType),
+			},
+			wantErr: true,
+		},
+		{
+			name: "message count mismatch",
+			constraints: []*rtypes.ParameterConstraint{
+				fixedConstraint("message_type_0", cosmosMsgSendType),
+				fixedConstraint("message_data_0", cosmosMsgSendData),
+				fixedConstraint("message_type_1", cosmosMsgSendType),
+				fixedConstraint("message_data_1", cosmosMsgSendData),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rule := &rtypes.Rule{
+				Effect:               rtypes.Effect_EFFECT_ALLOW,
+				ParameterConstraints: tc.constraints,
+			}
+			err := (&cosmosEngine{}).Evaluate(rule, []byte(cosmosDirectPayload))
+			if tc.wantErr && err == nil {
+				t.Error("Evaluate() accepted a tx the rule does not describe")
+			}
+			if !tc.wantErr && err != nil {
+				t.Errorf("Evaluate() error = %v", err)
+			}
+		})
+	}
+}
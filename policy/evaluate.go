@@ -0,0 +1,176 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/vultisig/pluginagent/proposal"
+	"github.com/vultisig/recipes/engine/btc"
+	"github.com/vultisig/recipes/engine/evm"
+	rtypes "github.com/vultisig/recipes/types"
+	"github.com/vultisig/recipes/util"
+	"github.com/vultisig/verifier/types"
+	vgcommon "github.com/vultisig/vultisig-go/common"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	ErrPolicyInactive    = errors.New("policy is not active")
+	ErrChainNotSupported = errors.New("chain is not supported")
+	// ErrChainMismatch is returned when none of the policy rules targets the transaction chain.
+	ErrChainMismatch = errors.New("policy has no rule for chain")
+)
+
+// RuleFailure describes why a single policy rule did not match a transaction.
+type RuleFailure struct {
+	RuleID   string `json:"rule_id"`
+	Resource string `json:"resource"`
+	Reason   string `json:"reason"`
+}
+
+// RuleViolationError is returned when a transaction is not allowed by any rule of a policy.
+type RuleViolationError struct {
+	Failures []RuleFailure
+}
+
+func (e *RuleViolationError) Error() string {
+	if len(e.Failures) == 0 {
+		return "transaction does not match any policy rule"
+	}
+
+	reasons := make([]string, 0, len(e.Failures))
+	for _, f := range e.Failures {
+		reasons = append(reasons, fmt.Sprintf("rule %s (%s): %s", f.RuleID, f.Resource, f.Reason))
+	}
+	return "transaction does not match any policy rule: " + strings.Join(reasons, "; ")
+}
+
+// chainEngine evaluates a single rule against a transaction of one chain family.
+type chainEngine interface {
+	Evaluate(rule *rtypes.Rule, txBytes []byte) error
+}
+
+// newChainEngine returns the recipes engine for the chain. Litecoin and Dogecoin are evaluated
+// locally since the BTC engine decodes output addresses with bitcoin mainnet parameters, and so
+// are Solana and Cosmos chains until the recipes module ships engines for them.
+func newChainEngine(chain vgcommon.Chain) (chainEngine, error) {
+	switch {
+	case chain.IsEvm():
+		nativeSymbol, err := chain.NativeSymbol()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get native symbol for chain %s: %w", chain.String(), err)
+		}
+		evmEngine, err := evm.NewEvm(nativeSymbol)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create EVM engine: %w", err)
+		}
+		return evmEngine, nil
+	case chain == vgcommon.Bitcoin:
+		return btc.NewBtc(), nil
+	case proposal.IsUtxoChain(chain):
+		engine, ok := newUtxoEngine(chain)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrChainNotSupported, chain.String())
+		}
+		return engine, nil
+	case chain == vgcommon.Solana:
+		return &solanaEngine{}, nil
+	case proposal.IsCosmosChain(chain):
+		return &cosmosEngine{}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrChainNotSupported, chain.String())
+	}
+}
+
+// RuleKey identifies a rule of a recipe in violations, spend limits and revision diffs: its ID,
+// or its position for rules without one.
+func RuleKey(rule *rtypes.Rule, index int) string {
+	if rule.GetId() != "" {
+		return rule.GetId()
+	}
+	return "#" + strconv.Itoa(index)
+}
+
+// RecipeRuleKey returns the key of a rule taken from another decoding of the recipe.
+func RecipeRuleKey(recipe *rtypes.Policy, rule *rtypes.Rule) string {
+	for i, r := range recipe.GetRules() {
+		if proto.Equal(r, rule) {
+			return RuleKey(r, i)
+		}
+	}
+	return rule.GetId()
+}
+
+// ValidateTransaction checks the unsigned transaction against every rule of the policy recipe
+// and returns the first rule that allows it. Resource path, target, function selector and
+// parameter constraints are all enforced by the recipes engine of the chain.
+func (p *Policy) ValidateTransaction(
+	policy types.PluginPolicy,
+	chain vgcommon.Chain,
+	tx []byte,
+) (*rtypes.Rule, error) {
+	if !policy.Active {
+		return nil, fmt.Errorf("%w: %s", ErrPolicyInactive, policy.ID)
+	}
+
+	recipe, err := policy.GetRecipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode policy recipe: %w", err)
+	}
+
+	engine, err := newChainEngine(chain)
+	if err != nil {
+		return nil, err
+	}
+
+	violation := &RuleViolationError{}
+	chainRules := 0
+	for i, rule := range recipe.GetRules() {
+		if rule == nil {
+			continue
+		}
+
+		ruleID := RuleKey(rule, i)
+
+		resource, er := util.ParseResource(rule.GetResource())
+		if er != nil {
+			violation.Failures = append(violation.Failures, RuleFailure{
+				RuleID:   ruleID,
+				Resource: rule.GetResource(),
+				Reason:   fmt.Sprintf("invalid resource path: %v", er),
+			})
+			continue
+		}
+
+		if resource.ChainId != strings.ToLower(chain.String()) {
+			violation.Failures = append(violation.Failures, RuleFailure{
+				RuleID:   ruleID,
+				Resource: rule.GetResource(),
+				Reason:   fmt.Sprintf("rule targets chain %s, not %s", resource.ChainId, chain.String()),
+			})
+			continue
+		}
+		chainRules++
+
+		if er := engine.Evaluate(rule, tx); er != nil {
+			violation.Failures = append(violation.Failures, RuleFailure{
+				RuleID:   ruleID,
+				Resource: rule.GetResource(),
+				Reason:   er.Error(),
+			})
+			continue
+		}
+
+		p.logger.WithField("policy_id", policy.ID).
+			WithField("rule_id", ruleID).
+			Info("transaction allowed by policy rule")
+		return rule, nil
+	}
+
+	if chainRules == 0 {
+		return nil, fmt.Errorf("%w %s", ErrChainMismatch, chain.String())
+	}
+	return nil, violation
+}
@@ -0,0 +1,102 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/vultisig/pluginagent/storage/interfaces"
+	ptypes "github.com/vultisig/pluginagent/types"
+	rtypes "github.com/vultisig/recipes/types"
+	"github.com/vultisig/verifier/types"
+)
+
+// AllowedTx is a transaction payload of a proposal along with the rule that allowed it.
+type AllowedTx struct {
+	Rule    *rtypes.Rule
+	Payload []byte
+}
+
+// ReserveProposal checks a new proposal against the schedule of the policy and the spend limits
+// of the rules that allowed its transactions, then records it in the execution and spend ledgers.
+// Every transaction of a batch proposal counts as an execution and its spends add up, so the
+// batch is checked as a unit. Every proposal of the policy except failed ones counts, and the policy ledgers are locked
+// while checking, so concurrent proposals can't exceed the limits.
+func (p *Policy) ReserveProposal(
+	ctx context.Context,
+	policy types.PluginPolicy,
+	proposal ptypes.Proposal,
+	txs []AllowedTx,
+) error {
+	recipe, err := policy.GetRecipe()
+	if err != nil {
+		return fmt.Errorf("failed to decode policy recipe: %w", err)
+	}
+	schedule := recipeSchedule(recipe)
+
+	amounts := make(map[string]*big.Int)
+	var limits []SpendLimit
+	seen := make(map[string]bool)
+	for i, tx := range txs {
+		txAmounts, txLimits, er := ruleSpends(RecipeRuleKey(recipe, tx.Rule), tx.Rule, tx.Payload)
+		if er != nil {
+			if len(txs) > 1 {
+				return fmt.Errorf("transaction %d: %w", i, er)
+			}
+			return er
+		}
+		for token, amount := range txAmounts {
+			if amounts[token] == nil {
+				amounts[token] = new(big.Int)
+			}
+			amounts[token].Add(amounts[token], amount)
+		}
+		for _, limit := range txLimits {
+			key := limit.RuleID + "/" + limit.Token + "/" + limit.Parameter
+			if !seen[key] {
+				seen[key] = true
+				limits = append(limits, limit)
+			}
+		}
+	}
+	if schedule == nil && len(amounts) == 0 {
+		return nil
+	}
+
+	return p.repo.WithTx(ctx, func(db interfaces.DatabaseStorage) error {
+		if er := db.LockPolicyLedgers(ctx, policy.ID); er != nil {
+			return er
+		}
+
+		if schedule != nil {
+			if er := checkSchedule(ctx, db, policy.ID, *schedule, len(txs)); er != nil {
+				return er
+			}
+		}
+		if er := checkSpends(ctx, db, policy.ID, amounts, limits, time.Now()); er != nil {
+			return er
+		}
+
+		if schedule != nil {
+			for i := range txs {
+				if er := db.InsertPolicyExecution(ctx, proposal.ID, policy.ID, i); er != nil {
+					return er
+				}
+			}
+		}
+		for token, amount := range amounts {
+			er := db.InsertSpendEntry(ctx, ptypes.SpendEntry{
+				ProposalID: proposal.ID,
+				PolicyID:   policy.ID,
+				Chain:      proposal.Chain,
+				Token:      token,
+				Amount:     amount,
+			})
+			if er != nil {
+				return er
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,224 @@
+package policy
+
+import (
+	"fmt"
+	"math/big"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	etypes "github.com/ethereum/go-ethereum/core/types"
+	abiembed "github.com/vultisig/recipes/abi"
+	"github.com/vultisig/recipes/resolver"
+	rtypes "github.com/vultisig/recipes/types"
+	vgcommon "github.com/vultisig/vultisig-go/common"
+)
+
+// DecodedParameter is a named argument of a call. Integers are decimal strings, addresses and
+// bytes hex strings, tuples objects keyed by component name.
+type DecodedParameter struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value any    `json:"value"`
+}
+
+// ResourceCall is a transaction understood as a call of a recipe resource.
+type ResourceCall struct {
+	Resource   string
+	ProtocolID string
+	FunctionID string
+	// Signature is the ABI signature of the method, e.g. transfer(address,uint256)
+	Signature  string
+	Parameters []DecodedParameter
+}
+
+var (
+	embeddedAbisOnce sync.Once
+	embeddedAbis     map[string]abi.ABI
+	embeddedAbisErr  error
+)
+
+// loadEmbeddedAbis parses the ABIs the recipes EVM engine evaluates rules with, keyed by the
+// protocol ID of the resource paths.
+func loadEmbeddedAbis() (map[string]abi.ABI, error) {
+	embeddedAbisOnce.Do(func() {
+		entries, err := abiembed.Dir.ReadDir(".")
+		if err != nil {
+			embeddedAbisErr = fmt.Errorf("failed to read abi dir: %w", err)
+			return
+		}
+		abis := make(map[string]abi.ABI, len(entries))
+		for _, entry := range entries {
+			if entry.IsDir() || path.Ext(entry.Name()) != ".json" {
+				continue
+			}
+			file, er := abiembed.Dir.Open(entry.Name())
+			if er != nil {
+				embeddedAbisErr = fmt.Errorf("failed to open abi %s: %w", entry.Name(), er)
+				return
+			}
+			contractAbi, er := abi.JSON(file)
+			_ = file.Close()
+			if er != nil {
+				embeddedAbisErr = fmt.Errorf("failed to parse abi %s: %w", entry.Name(), er)
+				return
+			}
+			abis[strings.TrimSuffix(entry.Name(), ".json")] = contractAbi
+		}
+		embeddedAbis = abis
+	})
+	return embeddedAbis, embeddedAbisErr
+}
+
+// MatchEvmResources returns the recipe resources an EVM transaction can be a call of. Transfers
+// without call data map to the native transfer resource, contract calls to every protocol with a
+// method of the call selector that the arguments decode with, sorted by resource path.
+func MatchEvmResources(chain vgcommon.Chain, tx *etypes.Transaction) ([]ResourceCall, error) {
+	chainID := strings.ToLower(chain.String())
+	data := tx.Data()
+	if len(data) == 0 {
+		nativeSymbol, err := chain.NativeSymbol()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get native symbol for chain %s: %w", chain.String(), err)
+		}
+		protocolID := strings.ToLower(nativeSymbol)
+		var recipient any
+		if tx.To() != nil {
+			recipient = tx.To().Hex()
+		}
+		return []ResourceCall{{
+			Resource:   fmt.Sprintf("%s.%s.transfer", chainID, protocolID),
+			ProtocolID: protocolID,
+			FunctionID: "transfer",
+			Parameters: []DecodedParameter{
+				{Name: "recipient", Type: "address", Value: recipient},
+				{Name: "amount", Type: "uint256", Value: tx.Value().String()},
+			},
+		}}, nil
+	}
+
+	const selectorSize = 4
+	if len(data) < selectorSize {
+		return nil, fmt.Errorf("call data of %d bytes has no function selector", len(data))
+	}
+
+	abis, err := loadEmbeddedAbis()
+	if err != nil {
+		return nil, err
+	}
+
+	var calls []ResourceCall
+	for protocolID, contractAbi := range abis {
+		method, er := contractAbi.MethodById(data[:selectorSize])
+		if er != nil {
+			continue
+		}
+		args, er := method.Inputs.Unpack(data[selectorSize:])
+		if er != nil {
+			continue
+		}
+		params := make([]DecodedParameter, 0, len(method.Inputs))
+		for i, input := range method.Inputs {
+			params = append(params, DecodedParameter{
+				Name:  input.Name,
+				Type:  input.Type.String(),
+				Value: formatAbiValue(reflect.ValueOf(args[i])),
+			})
+		}
+		calls = append(calls, ResourceCall{
+			Resource:   fmt.Sprintf("%s.%s.%s", chainID, protocolID, method.Name),
+			ProtocolID: protocolID,
+			FunctionID: method.Name,
+			Signature:  method.Sig,
+			Parameters: params,
+		})
+	}
+	sort.Slice(calls, func(i, j int) bool {
+		return calls[i].Resource < calls[j].Resource
+	})
+	return calls, nil
+}
+
+// RuleTargetMatches reports whether the rule targets the recipient of the transaction. Rules
+// without a contract target match any recipient.
+func RuleTargetMatches(rule *rtypes.Rule, chainID string, to *common.Address) (bool, error) {
+	target := rule.GetTarget()
+	var address string
+	switch target.GetTargetType() {
+	case rtypes.TargetType_TARGET_TYPE_ADDRESS:
+		address = target.GetAddress()
+	case rtypes.TargetType_TARGET_TYPE_MAGIC_CONSTANT:
+		resolve, err := resolver.NewMagicConstantRegistry().GetResolver(target.GetMagicConstant())
+		if err != nil {
+			return false, fmt.Errorf("failed to get resolver for %s: %w", target.GetMagicConstant().String(), err)
+		}
+		address, _, err = resolve.Resolve(target.GetMagicConstant(), chainID, "default")
+		if err != nil {
+			return false, fmt.Errorf("failed to resolve %s: %w", target.GetMagicConstant().String(), err)
+		}
+	default:
+		return true, nil
+	}
+	return to != nil && *to == common.HexToAddress(address), nil
+}
+
+// formatAbiValue converts an unpacked ABI value into a JSON friendly value, keeping integers of
+// any size exact.
+func formatAbiValue(v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
+	}
+	switch value := v.Interface().(type) {
+	case *big.Int:
+		return value.String()
+	case common.Address:
+		return value.Hex()
+	case common.Hash:
+		return value.Hex()
+	case []byte:
+		return hexutil.Encode(value)
+	case string, bool:
+		return value
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fmt.Sprintf("%d", v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fmt.Sprintf("%d", v.Uint())
+	case reflect.Array:
+		// Fixed size byte arrays, e.g. bytes32
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			return hexutil.Encode(b)
+		}
+		fallthrough
+	case reflect.Slice:
+		items := make([]any, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, formatAbiValue(v.Index(i)))
+		}
+		return items
+	case reflect.Struct:
+		fields := make(map[string]any, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			name := field.Tag.Get("json")
+			if name == "" {
+				name = field.Name
+			}
+			fields[name] = formatAbiValue(v.Field(i))
+		}
+		return fields
+	case reflect.Ptr:
+		return formatAbiValue(v.Elem())
+	default:
+		return fmt.Sprintf("%v", v.Interface())
+	}
+}
@@ -0,0 +1,188 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	ptypes "github.com/vultisig/pluginagent/types"
+	rtypes "github.com/vultisig/recipes/types"
+	"github.com/vultisig/verifier/types"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+type RuleChange string
+
+const (
+	RuleAdded    RuleChange = "added"
+	RuleRemoved  RuleChange = "removed"
+	RuleModified RuleChange = "modified"
+)
+
+// RuleDiff is a rule that differs between two revisions. Rules are matched by ID, rules without
+// an ID by their position. Before and After are the rule in each revision, Fields the rule fields
+// that changed.
+type RuleDiff struct {
+	RuleID string          `json:"rule_id"`
+	Change RuleChange      `json:"change"`
+	Fields []string        `json:"fields,omitempty"`
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// RevisionDiff compares the decoded recipes of two revisions of a policy. Fields are the changed
+// fields of the revision and of the recipe besides its rules, e.g. active or
+// recipe.configuration. Unchanged rules are left out.
+type RevisionDiff struct {
+	PolicyID uuid.UUID  `json:"policy_id"`
+	From     int        `json:"from"`
+	To       int        `json:"to"`
+	Fields   []string   `json:"fields,omitempty"`
+	Rules    []RuleDiff `json:"rules"`
+}
+
+// ListPolicyRevisions lists the revisions of the policy, deleted policies included, oldest first.
+func (p *Policy) ListPolicyRevisions(ctx context.Context, policyID uuid.UUID) ([]ptypes.PluginPolicyRevision, error) {
+	return p.repo.ListPluginPolicyRevisions(ctx, policyID)
+}
+
+// DiffPolicyRevisions compares revision from of the policy with revision to.
+func (p *Policy) DiffPolicyRevisions(ctx context.Context, policyID uuid.UUID, from, to int) (*RevisionDiff, error) {
+	fromRevision, err := p.repo.GetPluginPolicyRevision(ctx, policyID, from)
+	if err != nil {
+		return nil, err
+	}
+	toRevision, err := p.repo.GetPluginPolicyRevision(ctx, policyID, to)
+	if err != nil {
+		return nil, err
+	}
+	return DiffRevisions(*fromRevision, *toRevision)
+}
+
+// DiffRevisions compares the decoded recipes of two revisions rule by rule.
+func DiffRevisions(from, to ptypes.PluginPolicyRevision) (*RevisionDiff, error) {
+	fromRecipe, err := revisionRecipe(from)
+	if err != nil {
+		return nil, err
+	}
+	toRecipe, err := revisionRecipe(to)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &RevisionDiff{
+		PolicyID: from.PolicyID,
+		From:     from.Revision,
+		To:       to.Revision,
+		Rules:    []RuleDiff{},
+	}
+	if from.PluginVersion != to.PluginVersion {
+		diff.Fields = append(diff.Fields, "plugin_version")
+	}
+	if from.PolicyVersion != to.PolicyVersion {
+		diff.Fields = append(diff.Fields, "policy_version")
+	}
+	if from.Active != to.Active {
+		diff.Fields = append(diff.Fields, "active")
+	}
+	for _, field := range changedFields(fromRecipe, toRecipe) {
+		if field != "rules" {
+			diff.Fields = append(diff.Fields, "recipe."+field)
+		}
+	}
+
+	fromRules := make(map[string]*rtypes.Rule, len(fromRecipe.GetRules()))
+	for i, rule := range fromRecipe.GetRules() {
+		fromRules[RuleKey(rule, i)] = rule
+	}
+	toKeys := make(map[string]bool, len(toRecipe.GetRules()))
+	for i, rule := range toRecipe.GetRules() {
+		key := RuleKey(rule, i)
+		toKeys[key] = true
+
+		before, ok := fromRules[key]
+		if !ok {
+			after, er := marshalRule(rule)
+			if er != nil {
+				return nil, er
+			}
+			diff.Rules = append(diff.Rules, RuleDiff{RuleID: key, Change: RuleAdded, After: after})
+			continue
+		}
+		fields := changedFields(before, rule)
+		if len(fields) == 0 {
+			continue
+		}
+		beforeJSON, er := marshalRule(before)
+		if er != nil {
+			return nil, er
+		}
+		afterJSON, er := marshalRule(rule)
+		if er != nil {
+			return nil, er
+		}
+		diff.Rules = append(diff.Rules, RuleDiff{
+			RuleID: key,
+			Change: RuleModified,
+			Fields: fields,
+			Before: beforeJSON,
+			After:  afterJSON,
+		})
+	}
+	for i, rule := range fromRecipe.GetRules() {
+		key := RuleKey(rule, i)
+		if toKeys[key] {
+			continue
+		}
+		before, er := marshalRule(rule)
+		if er != nil {
+			return nil, er
+		}
+		diff.Rules = append(diff.Rules, RuleDiff{RuleID: key, Change: RuleRemoved, Before: before})
+	}
+	return diff, nil
+}
+
+func revisionRecipe(revision ptypes.PluginPolicyRevision) (*rtypes.Policy, error) {
+	policy := types.PluginPolicy{Recipe: revision.Recipe}
+	recipe, err := policy.GetRecipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode recipe of revision %d: %w", revision.Revision, err)
+	}
+	return recipe, nil
+}
+
+func marshalRule(rule *rtypes.Rule) (json.RawMessage, error) {
+	buf, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(rule)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal rule: %w", err)
+	}
+	return buf, nil
+}
+
+// changedFields returns the names of the top level fields that differ between two messages of
+// the same type.
+func changedFields(a, b proto.Message) []string {
+	ma, mb := a.ProtoReflect(), b.ProtoReflect()
+	fields := ma.Descriptor().Fields()
+
+	var changed []string
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if !proto.Equal(onlyField(ma, fd), onlyField(mb, fd)) {
+			changed = append(changed, string(fd.Name()))
+		}
+	}
+	return changed
+}
+
+func onlyField(m protoreflect.Message, fd protoreflect.FieldDescriptor) proto.Message {
+	field := m.New()
+	if m.Has(fd) {
+		field.Set(fd, m.Get(fd))
+	}
+	return field.Interface()
+}
@@ -0,0 +1,87 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vultisig/pluginagent/storage/interfaces"
+	rtypes "github.com/vultisig/recipes/types"
+)
+
+// ErrBatchExceedsSchedule is returned for a batch with more transactions than the schedule of the
+// policy allows within a window, it can never be proposed.
+var ErrBatchExceedsSchedule = errors.New("batch exceeds the transactions allowed per window")
+
+// Schedule is the execution frequency of a policy: at most MaxTxs transactions within any rolling
+// window, e.g. an approve and a transferFrom once a week.
+type Schedule struct {
+	Window time.Duration
+	MaxTxs int
+}
+
+// recipeSchedule returns the schedule set by rate_limit_window and max_txs_per_window, which
+// defaults to a single transaction. A recipe without a window has no schedule.
+func recipeSchedule(recipe *rtypes.Policy) *Schedule {
+	if recipe.GetRateLimitWindow() == 0 {
+		return nil
+	}
+
+	maxTxs := 1
+	if recipe.GetMaxTxsPerWindow() > 0 {
+		maxTxs = int(recipe.GetMaxTxsPerWindow())
+	}
+	return &Schedule{
+		Window: time.Duration(recipe.GetRateLimitWindow()) * time.Second,
+		MaxTxs: maxTxs,
+	}
+}
+
+// ScheduleError is returned when a proposal exceeds the execution frequency of the policy.
+type ScheduleError struct {
+	Schedule
+	Executed      int
+	NextAllowedAt time.Time
+}
+
+func (e *ScheduleError) Error() string {
+	return fmt.Sprintf(
+		"policy allows %d transaction(s) every %s, %d already proposed, next allowed at %s",
+		e.MaxTxs,
+		e.Window.String(),
+		e.Executed,
+		e.NextAllowedAt.Format(time.RFC3339),
+	)
+}
+
+// checkSchedule fails if the txCount transactions of a proposal don't fit in the window next to
+// the executions it already holds. The proposal is allowed once enough of the oldest executions
+// fall out of the window.
+func checkSchedule(
+	ctx context.Context,
+	db interfaces.DatabaseStorage,
+	policyID uuid.UUID,
+	schedule Schedule,
+	txCount int,
+) error {
+	if txCount > schedule.MaxTxs {
+		return fmt.Errorf("%w: %d transactions, policy allows %d every %s",
+			ErrBatchExceedsSchedule, txCount, schedule.MaxTxs, schedule.Window.String())
+	}
+
+	times, err := db.GetPolicyExecutionTimes(ctx, policyID, schedule.Window)
+	if err != nil {
+		return err
+	}
+	if len(times)+txCount <= schedule.MaxTxs {
+		return nil
+	}
+
+	return &ScheduleError{
+		Schedule:      schedule,
+		Executed:      len(times),
+		NextAllowedAt: times[len(times)+txCount-schedule.MaxTxs-1].Add(schedule.Window).UTC(),
+	}
+}
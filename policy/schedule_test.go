@@ -0,0 +1,61 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vultisig/pluginagent/storage/interfaces"
+)
+
+// scheduleDB serves the execution times of a policy, every other storage call panics.
+type scheduleDB struct {
+	interfaces.DatabaseStorage
+	times []time.Time
+}
+
+func (db scheduleDB) GetPolicyExecutionTimes(context.Context, uuid.UUID, time.Duration) ([]time.Time, error) {
+	return db.times, nil
+}
+
+func TestCheckSchedule(t *testing.T) {
+	now := time.Now().UTC()
+	schedule := Schedule{Window: time.Hour, MaxTxs: 3}
+	executed := []time.Time{now.Add(-50 * time.Minute), now.Add(-40 * time.Minute)}
+
+	tests := []struct {
+		name          string
+		times         []time.Time
+		txCount       int
+		nextAllowedAt time.Time
+		wantErr       error
+	}{
+		{name: "batch fits", times: executed[:1], txCount: 2},
+		{name: "single tx fits", times: executed, txCount: 1},
+		{name: "batch exceeds window", times: executed, txCount: 2, nextAllowedAt: executed[0].Add(time.Hour)},
+		{name: "batch waits for both", times: executed, txCount: 3, nextAllowedAt: executed[1].Add(time.Hour)},
+		{name: "batch above max", txCount: 4, wantErr: ErrBatchExceedsSchedule},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkSchedule(context.Background(), scheduleDB{times: tc.times}, uuid.New(), schedule, tc.txCount)
+			var scheduleErr *ScheduleError
+			switch {
+			case tc.wantErr != nil:
+				if !errors.Is(err, tc.wantErr) {
+					t.Errorf("checkSchedule() error = %v, want %v", err, tc.wantErr)
+				}
+			case tc.nextAllowedAt.IsZero():
+				if err != nil {
+					t.Errorf("checkSchedule() error = %v", err)
+				}
+			case !errors.As(err, &scheduleErr):
+				t.Errorf("checkSchedule() error = %v, want a ScheduleError", err)
+			case !scheduleErr.NextAllowedAt.Equal(tc.nextAllowedAt):
+				t.Errorf("NextAllowedAt = %s, want %s", scheduleErr.NextAllowedAt, tc.nextAllowedAt)
+			}
+		})
+	}
+}
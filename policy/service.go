@@ -7,7 +7,10 @@ import (
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"github.com/vultisig/pluginagent/storage/interfaces"
+	ptypes "github.com/vultisig/pluginagent/types"
+	rtypes "github.com/vultisig/recipes/types"
 	"github.com/vultisig/verifier/types"
+	vgcommon "github.com/vultisig/vultisig-go/common"
 )
 
 var _ Service = (*Policy)(nil)
@@ -16,13 +19,18 @@ type Service interface {
 	CreatePolicy(ctx context.Context, policy types.PluginPolicy) (*types.PluginPolicy, error)
 	UpdatePolicy(ctx context.Context, policy types.PluginPolicy) (*types.PluginPolicy, error)
 	DeletePolicy(ctx context.Context, policyID uuid.UUID, signature string) error
-	GetPluginPolicies(
-		ctx context.Context,
-		pluginID types.PluginID,
-		publicKey string,
-		onlyActive bool,
-	) ([]types.PluginPolicy, error)
+	ListPluginPolicies(ctx context.Context, filter ptypes.PluginPolicyFilter) ([]ptypes.PluginPolicyRecord, error)
 	GetPluginPolicy(ctx context.Context, policyID uuid.UUID) (*types.PluginPolicy, error)
+	ListPolicyRevisions(ctx context.Context, policyID uuid.UUID) ([]ptypes.PluginPolicyRevision, error)
+	DiffPolicyRevisions(ctx context.Context, policyID uuid.UUID, from, to int) (*RevisionDiff, error)
+	ValidateTransaction(policy types.PluginPolicy, chain vgcommon.Chain, tx []byte) (*rtypes.Rule, error)
+	ReserveProposal(
+		ctx context.Context,
+		policy types.PluginPolicy,
+		proposal ptypes.Proposal,
+		txs []AllowedTx,
+	) error
+	GetSpendUsage(ctx context.Context, policy types.PluginPolicy) ([]SpendUsage, error)
 }
 
 type Policy struct {
@@ -40,8 +48,18 @@ func NewPolicyService(
 	}, nil
 }
 
+// CreatePolicy stores the policy along with its first revision.
 func (p *Policy) CreatePolicy(c context.Context, policy types.PluginPolicy) (*types.PluginPolicy, error) {
-	newPolicy, err := p.repo.InsertPluginPolicy(c, policy)
+	var newPolicy *types.PluginPolicy
+	err := p.repo.WithTx(c, func(db interfaces.DatabaseStorage) error {
+		var er error
+		newPolicy, er = db.InsertPluginPolicy(c, policy)
+		if er != nil {
+			return er
+		}
+		_, er = db.InsertPluginPolicyRevision(c, newPolicy.ID)
+		return er
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to insert policy: %w", err)
 	}
@@ -49,8 +67,18 @@ func (p *Policy) CreatePolicy(c context.Context, policy types.PluginPolicy) (*ty
 	return newPolicy, nil
 }
 
+// UpdatePolicy overwrites the policy and records it as its next revision.
 func (p *Policy) UpdatePolicy(c context.Context, policy types.PluginPolicy) (*types.PluginPolicy, error) {
-	updatedPolicy, err := p.repo.UpdatePluginPolicy(c, policy)
+	var updatedPolicy *types.PluginPolicy
+	err := p.repo.WithTx(c, func(db interfaces.DatabaseStorage) error {
+		var er error
+		updatedPolicy, er = db.UpdatePluginPolicy(c, policy)
+		if er != nil {
+			return er
+		}
+		_, er = db.InsertPluginPolicyRevision(c, updatedPolicy.ID)
+		return er
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to update policy: %w", err)
 	}
@@ -67,13 +95,11 @@ func (p *Policy) DeletePolicy(c context.Context, policyID uuid.UUID, signature s
 	return nil
 }
 
-func (p *Policy) GetPluginPolicies(
+func (p *Policy) ListPluginPolicies(
 	ctx context.Context,
-	pluginID types.PluginID,
-	publicKey string,
-	onlyActive bool,
-) ([]types.PluginPolicy, error) {
-	return p.repo.GetAllPluginPolicies(ctx, publicKey, pluginID, onlyActive)
+	filter ptypes.PluginPolicyFilter,
+) ([]ptypes.PluginPolicyRecord, error) {
+	return p.repo.ListPluginPolicies(ctx, filter)
 }
 
 func (p *Policy) GetPluginPolicy(ctx context.Context, policyID uuid.UUID) (*types.PluginPolicy, error) {
@@ -0,0 +1,50 @@
+package policy
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/vultisig/pluginagent/proposal"
+	rtypes "github.com/vultisig/recipes/types"
+)
+
+var solanaInstructionFields = []string{"instruction_program_id", "instruction_data"}
+
+// solanaEngine evaluates rules against Solana messages. The recipes module has no Solana engine
+// yet, so it follows the conventions of the recipes BTC engine: every instruction of the message
+// must be described by an instruction_program_id_<i> and an instruction_data_<i> constraint,
+// with the data matched as lowercase hex.
+type solanaEngine struct{}
+
+func (e *solanaEngine) Evaluate(rule *rtypes.Rule, txBytes []byte) error {
+	if rule.GetEffect() != rtypes.Effect_EFFECT_ALLOW {
+		return fmt.Errorf("only allow rules supported, got: %s", rule.GetEffect().String())
+	}
+	if rule.GetTarget() != nil {
+		return fmt.Errorf("target must be nil for Solana, got: %s", rule.GetTarget().String())
+	}
+
+	msg, err := proposal.DecodeSolanaMessage(txBytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse solana message: %w", err)
+	}
+
+	constraints, err := parseIndexedConstraints(rule, solanaInstructionFields...)
+	if err != nil {
+		return err
+	}
+
+	values := make([]map[string]string, 0, len(msg.Instructions))
+	for i, inst := range msg.Instructions {
+		programID, er := msg.ProgramID(i)
+		if er != nil {
+			return er
+		}
+		values = append(values, map[string]string{
+			"instruction_program_id": programID,
+			"instruction_data":       hex.EncodeToString(inst.Data),
+		})
+	}
+
+	return validateIndexedConstraints("instruction", constraints, solanaInstructionFields, values)
+}
@@ -0,0 +1,352 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	etypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/google/uuid"
+	"github.com/vultisig/pluginagent/proposal"
+	"github.com/vultisig/pluginagent/storage/interfaces"
+	abiembed "github.com/vultisig/recipes/abi"
+	"github.com/vultisig/recipes/ethereum"
+	"github.com/vultisig/recipes/resolver"
+	rtypes "github.com/vultisig/recipes/types"
+	"github.com/vultisig/recipes/util"
+	"github.com/vultisig/verifier/types"
+	vgcommon "github.com/vultisig/vultisig-go/common"
+)
+
+// spendPeriods are the named periods a spend limit can use, any Go duration is accepted as well.
+var spendPeriods = map[string]time.Duration{
+	"hour":    time.Hour,
+	"hourly":  time.Hour,
+	"day":     24 * time.Hour,
+	"daily":   24 * time.Hour,
+	"week":    7 * 24 * time.Hour,
+	"weekly":  7 * 24 * time.Hour,
+	"month":   30 * 24 * time.Hour,
+	"monthly": 30 * 24 * time.Hour,
+}
+
+func parseSpendPeriod(period string) (time.Duration, error) {
+	if window, ok := spendPeriods[strings.ToLower(period)]; ok {
+		return window, nil
+	}
+	window, err := time.ParseDuration(period)
+	if err != nil || window <= 0 {
+		return 0, fmt.Errorf("invalid spend limit period %q", period)
+	}
+	return window, nil
+}
+
+// SpendLimit is a MAX constraint of a rule that has a period. Besides capping a single
+// transaction, the max value caps the sum of the parameter over a rolling window of the period.
+type SpendLimit struct {
+	RuleID    string
+	Chain     vgcommon.Chain
+	Token     string
+	Parameter string
+	Period    string
+	Window    time.Duration
+	Max       *big.Int
+}
+
+// SpendUsage is the amount of a spend limit used within its current window.
+type SpendUsage struct {
+	SpendLimit
+	Used        *big.Int
+	Remaining   *big.Int
+	WindowStart time.Time
+}
+
+// SpendLimitError is returned when a transaction would exceed a spend limit of the policy.
+type SpendLimitError struct {
+	Usage  SpendUsage
+	Amount *big.Int
+}
+
+func (e *SpendLimitError) Error() string {
+	return fmt.Sprintf(
+		"spend limit of rule %s exceeded: %s %s over %s, used %s, remaining %s, requested %s",
+		e.Usage.RuleID,
+		e.Usage.Max.String(),
+		e.Usage.Token,
+		e.Usage.Period,
+		e.Usage.Used.String(),
+		e.Usage.Remaining.String(),
+		e.Amount.String(),
+	)
+}
+
+// ruleSpendLimits returns the spend limits of the rule. Tokens are the native symbol for native
+// transfers and the lowercase contract address for EVM contract calls.
+func ruleSpendLimits(ruleID string, rule *rtypes.Rule) ([]SpendLimit, error) {
+	var limits []SpendLimit
+	for _, pc := range rule.GetParameterConstraints() {
+		constraint := pc.GetConstraint()
+		if constraint.GetType() != rtypes.ConstraintType_CONSTRAINT_TYPE_MAX || constraint.GetPeriod() == "" {
+			continue
+		}
+
+		resource, err := util.ParseResource(rule.GetResource())
+		if err != nil {
+			return nil, fmt.Errorf("invalid resource path: %w", err)
+		}
+		chain, err := vgcommon.FromString(resource.ChainId)
+		if err != nil {
+			return nil, err
+		}
+		window, err := parseSpendPeriod(constraint.GetPeriod())
+		if err != nil {
+			return nil, err
+		}
+		maxValue, ok := new(big.Int).SetString(constraint.GetMaxValue(), 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid max value %q of %s", constraint.GetMaxValue(), pc.GetParameterName())
+		}
+		token, err := spendToken(chain, resource, rule)
+		if err != nil {
+			return nil, err
+		}
+
+		limits = append(limits, SpendLimit{
+			RuleID:    ruleID,
+			Chain:     chain,
+			Token:     token,
+			Parameter: pc.GetParameterName(),
+			Period:    constraint.GetPeriod(),
+			Window:    window,
+			Max:       maxValue,
+		})
+	}
+	return limits, nil
+}
+
+func spendToken(chain vgcommon.Chain, resource *rtypes.ResourcePath, rule *rtypes.Rule) (string, error) {
+	nativeSymbol, err := chain.NativeSymbol()
+	if err != nil {
+		return "", fmt.Errorf("failed to get native symbol for chain %s: %w", chain.String(), err)
+	}
+
+	switch {
+	case chain.IsEvm() && resource.ProtocolId != strings.ToLower(nativeSymbol):
+		target := rule.GetTarget()
+		switch target.GetTargetType() {
+		case rtypes.TargetType_TARGET_TYPE_ADDRESS:
+			return strings.ToLower(common.HexToAddress(target.GetAddress()).Hex()), nil
+		case rtypes.TargetType_TARGET_TYPE_MAGIC_CONSTANT:
+			resolve, er := resolver.NewMagicConstantRegistry().GetResolver(target.GetMagicConstant())
+			if er != nil {
+				return "", fmt.Errorf("failed to get resolver for %s: %w", target.GetMagicConstant().String(), er)
+			}
+			address, _, er := resolve.Resolve(target.GetMagicConstant(), resource.ChainId, "default")
+			if er != nil {
+				return "", fmt.Errorf("failed to resolve %s: %w", target.GetMagicConstant().String(), er)
+			}
+			return strings.ToLower(common.HexToAddress(address).Hex()), nil
+		default:
+			return "", fmt.Errorf("spend limits need a contract target, got %s", target.GetTargetType().String())
+		}
+	case chain.IsEvm(), proposal.IsUtxoChain(chain):
+		return strings.ToLower(nativeSymbol), nil
+	default:
+		return "", fmt.Errorf("spend limits are not supported on chain %s", chain.String())
+	}
+}
+
+// spendAmount returns the value of the limited parameter in the policy payload of a transaction.
+func spendAmount(limit SpendLimit, resource *rtypes.ResourcePath, tx []byte) (*big.Int, error) {
+	switch {
+	case limit.Chain.IsEvm():
+		txData, err := ethereum.DecodeUnsignedPayload(tx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode tx payload: %w", err)
+		}
+		evmTx := etypes.NewTx(txData)
+		if resource.ProtocolId == limit.Token {
+			if limit.Parameter != "amount" {
+				return nil, fmt.Errorf("unknown native transfer parameter %s", limit.Parameter)
+			}
+			return evmTx.Value(), nil
+		}
+		return abiSpendAmount(resource, limit.Parameter, evmTx.Data())
+	case proposal.IsUtxoChain(limit.Chain):
+		indexStr, ok := strings.CutPrefix(limit.Parameter, "output_value_")
+		if !ok {
+			return nil, fmt.Errorf("spend limits are only supported on output_value parameters, got %s", limit.Parameter)
+		}
+		index, err := strconv.Atoi(indexStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid output index in %s", limit.Parameter)
+		}
+		msgTx := &wire.MsgTx{}
+		if err := msgTx.DeserializeNoWitness(bytes.NewReader(tx)); err != nil {
+			return nil, fmt.Errorf("failed to decode tx payload: %w", err)
+		}
+		if index < 0 || index >= len(msgTx.TxOut) {
+			return nil, fmt.Errorf("output %d is out of range", index)
+		}
+		return big.NewInt(msgTx.TxOut[index].Value), nil
+	default:
+		return nil, fmt.Errorf("spend limits are not supported on chain %s", limit.Chain.String())
+	}
+}
+
+func abiSpendAmount(resource *rtypes.ResourcePath, parameter string, data []byte) (*big.Int, error) {
+	file, err := abiembed.Dir.Open(resource.ProtocolId + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open abi of %s: %w", resource.ProtocolId, err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	contractAbi, err := abi.JSON(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse abi of %s: %w", resource.ProtocolId, err)
+	}
+	method, ok := contractAbi.Methods[resource.FunctionId]
+	if !ok {
+		return nil, fmt.Errorf("failed to find abi method %s", resource.FunctionId)
+	}
+
+	const selectorSize = 4
+	if len(data) < selectorSize {
+		return nil, errors.New("tx data has no function selector")
+	}
+	args, err := method.Inputs.Unpack(data[selectorSize:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack abi args: %w", err)
+	}
+	for i, input := range method.Inputs {
+		if input.Name != parameter {
+			continue
+		}
+		amount, ok := args[i].(*big.Int)
+		if !ok {
+			return nil, fmt.Errorf("parameter %s is not an integer", parameter)
+		}
+		return amount, nil
+	}
+	return nil, fmt.Errorf("parameter %s not found in %s", parameter, resource.FunctionId)
+}
+
+// ruleSpends returns the amount of every token the transaction moves that is subject to a spend
+// limit of the rule, along with those limits. Several limited parameters of the same token add up.
+func ruleSpends(ruleID string, rule *rtypes.Rule, tx []byte) (map[string]*big.Int, []SpendLimit, error) {
+	limits, err := ruleSpendLimits(ruleID, rule)
+	if err != nil || len(limits) == 0 {
+		return nil, nil, err
+	}
+	resource, err := util.ParseResource(rule.GetResource())
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid resource path: %w", err)
+	}
+
+	amounts := make(map[string]*big.Int)
+	counted := make(map[string]bool)
+	for _, limit := range limits {
+		if counted[limit.Token+"/"+limit.Parameter] {
+			continue
+		}
+		counted[limit.Token+"/"+limit.Parameter] = true
+
+		amount, er := spendAmount(limit, resource, tx)
+		if er != nil {
+			return nil, nil, fmt.Errorf("failed to get %s spend: %w", limit.Parameter, er)
+		}
+		if amounts[limit.Token] == nil {
+			amounts[limit.Token] = new(big.Int)
+		}
+		amounts[limit.Token].Add(amounts[limit.Token], amount)
+	}
+	return amounts, limits, nil
+}
+
+// checkSpends fails if the amount of a token exceeds the remaining allowance of one of its limits.
+func checkSpends(
+	ctx context.Context,
+	db interfaces.DatabaseStorage,
+	policyID uuid.UUID,
+	amounts map[string]*big.Int,
+	limits []SpendLimit,
+	now time.Time,
+) error {
+	for _, limit := range limits {
+		usage, err := spendUsage(ctx, db, policyID, limit, now)
+		if err != nil {
+			return err
+		}
+		amount := amounts[limit.Token]
+		if amount.Cmp(usage.Remaining) > 0 {
+			return &SpendLimitError{
+				Usage:  *usage,
+				Amount: amount,
+			}
+		}
+	}
+	return nil
+}
+
+// GetSpendUsage reports the usage of every spend limit of the policy recipe.
+func (p *Policy) GetSpendUsage(ctx context.Context, policy types.PluginPolicy) ([]SpendUsage, error) {
+	recipe, err := policy.GetRecipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode policy recipe: %w", err)
+	}
+
+	now := time.Now()
+	usages := make([]SpendUsage, 0)
+	for i, rule := range recipe.GetRules() {
+		if rule == nil {
+			continue
+		}
+		ruleID := RuleKey(rule, i)
+
+		limits, er := ruleSpendLimits(ruleID, rule)
+		if er != nil {
+			return nil, fmt.Errorf("invalid spend limit in rule %s: %w", ruleID, er)
+		}
+		for _, limit := range limits {
+			usage, e := spendUsage(ctx, p.repo, policy.ID, limit, now)
+			if e != nil {
+				return nil, e
+			}
+			usages = append(usages, *usage)
+		}
+	}
+	return usages, nil
+}
+
+func spendUsage(
+	ctx context.Context,
+	db interfaces.DatabaseStorage,
+	policyID uuid.UUID,
+	limit SpendLimit,
+	now time.Time,
+) (*SpendUsage, error) {
+	used, err := db.GetSpendUsage(ctx, policyID, limit.Chain, limit.Token, limit.Window)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := new(big.Int).Sub(limit.Max, used)
+	if remaining.Sign() < 0 {
+		remaining.SetInt64(0)
+	}
+	return &SpendUsage{
+		SpendLimit:  limit,
+		Used:        used,
+		Remaining:   remaining,
+		WindowStart: now.Add(-limit.Window).UTC(),
+	}, nil
+}
@@ -0,0 +1,172 @@
+package policy
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/vultisig/recipes/engine/compare"
+	rtypes "github.com/vultisig/recipes/types"
+	vgcommon "github.com/vultisig/vultisig-go/common"
+)
+
+var utxoOutputFields = []string{"output_address", "output_value", "output_data"}
+
+// utxoNetParams are the address encodings of the UTXO chains evaluated locally. Only the
+// address prefixes are set, nothing else of the parameters is used.
+var utxoNetParams = map[vgcommon.Chain]*chaincfg.Params{
+	vgcommon.Litecoin: {
+		Name:             "litecoin",
+		PubKeyHashAddrID: 0x30,
+		ScriptHashAddrID: 0x32,
+		Bech32HRPSegwit:  "ltc",
+	},
+	vgcommon.Dogecoin: {
+		Name:             "dogecoin",
+		PubKeyHashAddrID: 0x1e,
+		ScriptHashAddrID: 0x16,
+	},
+}
+
+// utxoEngine evaluates rules against Litecoin and Dogecoin transactions. The recipes BTC engine
+// decodes output addresses with bitcoin mainnet parameters, so the same output_address_<i>,
+// output_value_<i> and output_data_<i> constraints are evaluated here with the parameters of
+// the chain.
+type utxoEngine struct {
+	params *chaincfg.Params
+}
+
+func newUtxoEngine(chain vgcommon.Chain) (*utxoEngine, bool) {
+	params, ok := utxoNetParams[chain]
+	if !ok {
+		return nil, false
+	}
+	return &utxoEngine{params: params}, true
+}
+
+func (e *utxoEngine) Evaluate(rule *rtypes.Rule, txBytes []byte) error {
+	if rule.GetEffect() != rtypes.Effect_EFFECT_ALLOW {
+		return fmt.Errorf("only allow rules supported, got: %s", rule.GetEffect().String())
+	}
+	if rule.GetTarget() != nil {
+		return fmt.Errorf("target must be nil for %s, got: %s", e.params.Name, rule.GetTarget().String())
+	}
+
+	tx := &wire.MsgTx{}
+	if err := tx.DeserializeNoWitness(bytes.NewReader(txBytes)); err != nil {
+		return fmt.Errorf("failed to parse %s transaction: %w", e.params.Name, err)
+	}
+
+	constraints, err := parseIndexedConstraints(rule, utxoOutputFields...)
+	if err != nil {
+		return err
+	}
+	if len(constraints) != len(tx.TxOut) {
+		return fmt.Errorf("output count mismatch: rule has %d outputs, tx has %d outputs",
+			len(constraints), len(tx.TxOut))
+	}
+
+	for i, txOut := range tx.TxOut {
+		if err := e.validateOutput(i, constraints[i], txOut); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateOutput checks an output against its constraints. An output is described either by an
+// output_data constraint on its OP_RETURN data, or by output_address and output_value.
+func (e *utxoEngine) validateOutput(
+	index int,
+	constraints map[string]*rtypes.ParameterConstraint,
+	txOut *wire.TxOut,
+) error {
+	dataConstraint := constraints["output_data"]
+	addressConstraint := constraints["output_address"]
+	valueConstraint := constraints["output_value"]
+
+	if dataConstraint != nil {
+		if addressConstraint != nil || valueConstraint != nil {
+			return fmt.Errorf("output %d cannot have both data and address+value constraints", index)
+		}
+		if len(txOut.PkScript) < 2 || txOut.PkScript[0] != txscript.OP_RETURN {
+			return fmt.Errorf("output %d is not an OP_RETURN script", index)
+		}
+		pushes, err := txscript.PushedData(txOut.PkScript[1:])
+		if err != nil {
+			return fmt.Errorf("output %d has invalid OP_RETURN data: %w", index, err)
+		}
+		if err := validateStringConstraint(dataConstraint, string(bytes.Join(pushes, nil))); err != nil {
+			return fmt.Errorf("output %d data validation failed: %w", index, err)
+		}
+		return nil
+	}
+
+	if addressConstraint == nil || valueConstraint == nil {
+		return fmt.Errorf("output %d must have either data constraint or both address and value constraints", index)
+	}
+	address, err := e.outputAddress(txOut.PkScript)
+	if err != nil {
+		return fmt.Errorf("failed to extract address from output %d: %w", index, err)
+	}
+	if err := validateStringConstraint(addressConstraint, address); err != nil {
+		return fmt.Errorf("output %d address validation failed: %w", index, err)
+	}
+	if err := validateAmountConstraint(valueConstraint, big.NewInt(txOut.Value)); err != nil {
+		return fmt.Errorf("output %d value validation failed: %w", index, err)
+	}
+	return nil
+}
+
+func (e *utxoEngine) outputAddress(pkScript []byte) (string, error) {
+	class, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript, e.params)
+	if err != nil {
+		return "", err
+	}
+	switch class {
+	case txscript.PubKeyHashTy, txscript.ScriptHashTy:
+	case txscript.WitnessV0PubKeyHashTy, txscript.WitnessV0ScriptHashTy, txscript.WitnessV1TaprootTy:
+		if e.params.Bech32HRPSegwit == "" {
+			return "", fmt.Errorf("segwit outputs are not supported on %s", e.params.Name)
+		}
+	default:
+		return "", fmt.Errorf("unsupported output script type %s", class)
+	}
+	if len(addrs) == 0 {
+		return "", fmt.Errorf("no address found in script")
+	}
+	return addrs[0].EncodeAddress(), nil
+}
+
+// validateAmountConstraint compares an amount the way the recipes engines compare integers.
+func validateAmountConstraint(constraint *rtypes.ParameterConstraint, actual *big.Int) error {
+	c := constraint.GetConstraint()
+	var (
+		raw   string
+		match func(compare.Compare[*big.Int], *big.Int) bool
+	)
+	switch c.GetType() {
+	case rtypes.ConstraintType_CONSTRAINT_TYPE_ANY:
+		return nil
+	case rtypes.ConstraintType_CONSTRAINT_TYPE_FIXED:
+		raw, match = c.GetFixedValue(), compare.Compare[*big.Int].Fixed
+	case rtypes.ConstraintType_CONSTRAINT_TYPE_MIN:
+		raw, match = c.GetMinValue(), compare.Compare[*big.Int].Min
+	case rtypes.ConstraintType_CONSTRAINT_TYPE_MAX:
+		raw, match = c.GetMaxValue(), compare.Compare[*big.Int].Max
+	default:
+		return validateStringConstraint(constraint, actual.String())
+	}
+
+	comparer, err := compare.NewBigInt(raw)
+	if err != nil {
+		return err
+	}
+	if !match(comparer, actual) {
+		return fmt.Errorf("%s constraint failed: expected=%s, actual=%s", c.GetType().String(), raw, actual.String())
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package policy
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	rtypes "github.com/vultisig/recipes/types"
+	vgcommon "github.com/vultisig/vultisig-go/common"
+)
+
+// The outputs pay to the key hash 0x11 * 20, whose addresses are base58check encoded with the
+// version byte of each chain.
+const (
+	utxoFixtureLitecoinAddress = "LLnCCHbSzfwWquEdaS5TF2Yt7uz5Qb1SZ1"
+	utxoFixtureDogecoinAddress = "D6hLULEGDRbk86j58t5iWmeinqM6acA16V"
+	utxoFixtureBitcoinAddress  = "12ZEw5Hcv1hTb6YUQJ69y1V7uhcoDz92PH"
+)
+
+func utxoFixturePayload(t *testing.T, pkScripts ...[]byte) []byte {
+	t.Helper()
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 0}, nil, nil))
+	for _, pkScript := range pkScripts {
+		tx.AddTxOut(wire.NewTxOut(50_000, pkScript))
+	}
+	var buf bytes.Buffer
+	if err := tx.SerializeNoWitness(&buf); err != nil {
+		t.Fatalf("failed to serialize tx: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func utxoFixtureScript(t *testing.T, builder *txscript.ScriptBuilder) []byte {
+	t.Helper()
+	script, err := builder.Script()
+	if err != nil {
+		t.Fatalf("failed to build script: %v", err)
+	}
+	return script
+}
+
+func TestUtxoEngineEvaluate(t *testing.T) {
+	keyHash := bytes.Repeat([]byte{0x11}, 20)
+	p2pkh := utxoFixtureScript(t, txscript.NewScriptBuilder().
+		AddOp(txscript.OP_DUP).AddOp(txscript.OP_HASH160).AddData(keyHash).
+		AddOp(txscript.OP_EQUALVERIFY).AddOp(txscript.OP_CHECKSIG))
+	p2wpkh := utxoFixtureScript(t, txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(keyHash))
+	opReturn := utxoFixtureScript(t, txscript.NewScriptBuilder().AddOp(txscript.OP_RETURN).AddData([]byte("memo")))
+
+	tests := []struct {
+		name        string
+		chain       vgcommon.Chain
+		payload     []byte
+		constraints []*rtypes.ParameterConstraint
+		wantErr     bool
+	}{
+		{
+			name:    "litecoin address",
+			chain:   vgcommon.Litecoin,
+			payload: utxoFixturePayload(t, p2pkh),
+			constraints: []*rtypes.ParameterConstraint{
+				fixedConstraint("output_address_0", utxoFixtureLitecoinAddress),
+				fixedConstraint("output_value_0", "50000"),
+			},
+		},
+		{
+			name:    "dogecoin address",
+			chain:   vgcommon.Dogecoin,
+			payload: utxoFixturePayload(t, p2pkh),
+			constraints: []*rtypes.ParameterConstraint{
+				fixedConstraint("output_address_0", utxoFixtureDogecoinAddress),
+				fixedConstraint("output_value_0", "50000"),
+			},
+		},
+		{
+			name:    "bitcoin address on litecoin",
+			chain:   vgcommon.Litecoin,
+			payload: utxoFixturePayload(t, p2pkh),
+			constraints: []*rtypes.ParameterConstraint{
+				fixedConstraint("output_address_0", utxoFixtureBitcoinAddress),
+				fixedConstraint("output_value_0", "50000"),
+			},
+			wantErr: true,
+		},
+		{
+			name:    "value above max",
+			chain:   vgcommon.Litecoin,
+			payload: utxoFixturePayload(t, p2pkh),
+			constraints: []*rtypes.ParameterConstraint{
+				fixedConstraint("output_address_0", utxoFixtureLitecoinAddress),
+				{
+					ParameterName: "output_value_0",
+					Constraint: &rtypes.Constraint{
+						Type:  rtypes.ConstraintType_CONSTRAINT_TYPE_MAX,
+						Value: &rtypes.Constraint_MaxValue{MaxValue: "49999"},
+					},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "op_return data",
+			chain:   vgcommon.Litecoin,
+			payload: utxoFixturePayload(t, p2pkh, opReturn),
+			constraints: []*rtypes.ParameterConstraint{
+				fixedConstraint("output_address_0", utxoFixtureLitecoinAddress),
+				fixedConstraint("output_value_0", "50000"),
+				fixedConstraint("output_data_1", "memo"),
+			},
+		},
+		{
+			name:    "segwit output on dogecoin",
+			chain:   vgcommon.Dogecoin,
+			payload: utxoFixturePayload(t, p2wpkh),
+			constraints: []*rtypes.ParameterConstraint{
+				fixedConstraint("output_address_0", utxoFixtureDogecoinAddress),
+				fixedConstraint("output_value_0", "50000"),
+			},
+			wantErr: true,
+		},
+		{
+			name:    "output count mismatch",
+			chain:   vgcommon.Litecoin,
+			payload: utxoFixturePayload(t, p2pkh, p2pkh),
+			constraints: []*rtypes.ParameterConstraint{
+				fixedConstraint("output_address_0", utxoFixtureLitecoinAddress),
+				fixedConstraint("output_value_0", "50000"),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			engine, err := newChainEngine(tc.chain)
+			if err != nil {
+				t.Fatalf("newChainEngine() error = %v", err)
+			}
+			rule := &rtypes.Rule{
+				Effect:               rtypes.Effect_EFFECT_ALLOW,
+				ParameterConstraints: tc.constraints,
+			}
+			err = engine.Evaluate(rule, tc.payload)
+			if tc.wantErr && err == nil {
+				t.Error("Evaluate() accepted a tx the rule does not describe")
+			}
+			if !tc.wantErr && err != nil {
+				t.Errorf("Evaluate() error = %v", err)
+			}
+		})
+	}
+}
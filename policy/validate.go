@@ -0,0 +1,313 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kaptinlin/jsonschema"
+	rtypes "github.com/vultisig/recipes/types"
+	"github.com/vultisig/verifier/types"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// FeeCeilingsKey is the recipe configuration field policies narrow the fee ceilings of the agent
+// with. The field belongs to the agent, so it is checked against feeCeilingsSchema and plugin
+// configuration schemas don't have to declare it.
+const FeeCeilingsKey = "fee_ceilings"
+
+var feeAmountSchema = map[string]any{
+	"type":    []any{"integer", "string"},
+	"format":  "integer",
+	"minimum": float64(0),
+}
+
+var feeCeilingsSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"max_gas_limit":   feeAmountSchema,
+		"max_fee_per_gas": feeAmountSchema,
+		"max_total_fee":   feeAmountSchema,
+	},
+	"additionalProperties": false,
+}
+
+// FieldError is a violation of the recipe specification by a field of a policy. Field is the path
+// of the field in the policy, e.g. recipe.rules[0].parameter_constraints[1].constraint.type.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError is returned when a policy does not satisfy the recipe specification of its
+// plugin.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", fe.Field, fe.Message))
+	}
+	return "policy does not satisfy the recipe specification: " + strings.Join(msgs, "; ")
+}
+
+// LoadRecipeSchema reads the recipe specification file of the plugin.
+func LoadRecipeSchema(path string) (*rtypes.RecipeSchema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recipe specification: %w", err)
+	}
+
+	var schema rtypes.RecipeSchema
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse recipe specification: %w", err)
+	}
+	return &schema, nil
+}
+
+// ValidatePolicy checks the recipe of the policy against the recipe specification. Rules may only
+// use supported resources, constrain parameters with their supported constraint type and must
+// constrain every required parameter. The recipe configuration must satisfy the configuration
+// JSON schema, except for the fee ceilings of the agent. Every violation is returned in a
+// ValidationError.
+func ValidatePolicy(policy types.PluginPolicy, schema *rtypes.RecipeSchema) error {
+	recipe, err := policy.GetRecipe()
+	if err != nil {
+		return &ValidationError{Errors: []FieldError{{
+			Field:   "recipe",
+			Message: fmt.Sprintf("failed to decode recipe: %v", err),
+		}}}
+	}
+
+	resources := make(map[string]*rtypes.ResourcePattern, len(schema.GetSupportedResources()))
+	for _, resource := range schema.GetSupportedResources() {
+		resources[resource.GetResourcePath().GetFull()] = resource
+	}
+
+	var errs []FieldError
+	if len(recipe.GetRules()) == 0 {
+		errs = append(errs, FieldError{Field: "recipe.rules", Message: "policy has no rules"})
+	}
+	for i, rule := range recipe.GetRules() {
+		field := fmt.Sprintf("recipe.rules[%d]", i)
+		resource, ok := resources[rule.GetResource()]
+		if !ok {
+			errs = append(errs, FieldError{
+				Field:   field + ".resource",
+				Message: fmt.Sprintf("resource %q is not supported by the plugin", rule.GetResource()),
+			})
+			continue
+		}
+		errs = append(errs, validateRuleParameters(field, rule, resource)...)
+	}
+
+	var configuration map[string]any
+	if recipe.GetConfiguration() != nil {
+		configuration = recipe.GetConfiguration().AsMap()
+	} else {
+		configuration = map[string]any{}
+	}
+	if feeCeilings, ok := configuration[FeeCeilingsKey]; ok {
+		errs = append(errs, validateJSONSchema("recipe.configuration."+FeeCeilingsKey, feeCeilingsSchema, feeCeilings)...)
+		delete(configuration, FeeCeilingsKey)
+	}
+	if schema.GetConfiguration() != nil {
+		errs = append(errs, validateJSONSchema("recipe.configuration", schema.GetConfiguration().AsMap(), configuration)...)
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+func validateRuleParameters(field string, rule *rtypes.Rule, resource *rtypes.ResourcePattern) []FieldError {
+	capabilities := make(map[string]*rtypes.ParameterConstraintCapability, len(resource.GetParameterCapabilities()))
+	for _, capability := range resource.GetParameterCapabilities() {
+		capabilities[capability.GetParameterName()] = capability
+	}
+
+	var errs []FieldError
+	constrained := make(map[string]bool, len(rule.GetParameterConstraints()))
+	for i, constraint := range rule.GetParameterConstraints() {
+		name := constraint.GetParameterName()
+		constrained[name] = true
+		capability, ok := capabilities[name]
+		if !ok {
+			errs = append(errs, FieldError{
+				Field:   fmt.Sprintf("%s.parameter_constraints[%d].parameter_name", field, i),
+				Message: fmt.Sprintf("parameter %q is not a parameter of %s", name, rule.GetResource()),
+			})
+			continue
+		}
+		if constraint.GetConstraint().GetType() != capability.GetSupportedTypes() {
+			errs = append(errs, FieldError{
+				Field: fmt.Sprintf("%s.parameter_constraints[%d].constraint.type", field, i),
+				Message: fmt.Sprintf("parameter %q supports %s constraints, got %s",
+					name, capability.GetSupportedTypes().String(), constraint.GetConstraint().GetType().String()),
+			})
+		}
+	}
+
+	for _, capability := range resource.GetParameterCapabilities() {
+		if capability.GetRequired() && !constrained[capability.GetParameterName()] {
+			errs = append(errs, FieldError{
+				Field:   field + ".parameter_constraints",
+				Message: fmt.Sprintf("required parameter %q is not constrained", capability.GetParameterName()),
+			})
+		}
+	}
+	return errs
+}
+
+// validateJSONSchema validates a value against a JSON schema of the recipe specification. The
+// number and integer formats check decimal strings, formats the validator doesn't know are
+// ignored like the recipes engine ignores them.
+func validateJSONSchema(field string, schema map[string]any, value any) []FieldError {
+	compiled, err := compileJSONSchema(schema)
+	if err != nil {
+		return []FieldError{{
+			Field:   field,
+			Message: fmt.Sprintf("invalid JSON schema in recipe specification: %v", err),
+		}}
+	}
+	errs := schemaResultErrors(field, compiled.Validate(value), value)
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+	return errs
+}
+
+func compileJSONSchema(schema map[string]any) (*jsonschema.Schema, error) {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	compiler := jsonschema.NewCompiler().SetAssertFormat(true)
+	for _, format := range schemaFormats(schema, nil) {
+		if _, ok := jsonschema.Formats[format]; !ok {
+			compiler.RegisterFormat(format, func(any) bool { return true })
+		}
+	}
+	compiler.RegisterFormat("number", func(v any) bool {
+		s, _ := v.(string)
+		return decimalNumber.MatchString(s)
+	}, "string")
+	compiler.RegisterFormat("integer", func(v any) bool {
+		s, _ := v.(string)
+		_, ok := new(big.Int).SetString(s, 10)
+		return ok
+	}, "string")
+	return compiler.Compile(data)
+}
+
+// schemaFormats lists the format keywords used anywhere in the schema.
+func schemaFormats(schema any, formats []string) []string {
+	switch v := schema.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if format, ok := item.(string); ok && key == "format" {
+				formats = append(formats, format)
+				continue
+			}
+			formats = schemaFormats(item, formats)
+		}
+	case []any:
+		for _, item := range v {
+			formats = schemaFormats(item, formats)
+		}
+	}
+	return formats
+}
+
+// schemaSubresultKeywords fail only when the results of subschemas failed, the failures of the
+// subschemas are reported instead.
+var schemaSubresultKeywords = map[string]bool{
+	"$ref":                 true,
+	"allOf":                true,
+	"properties":           true,
+	"additionalProperties": true,
+	"items":                true,
+	"prefixItems":          true,
+	"then":                 true,
+	"else":                 true,
+	"dependentSchemas":     true,
+}
+
+// schemaResultErrors turns a failed validation of value into field errors. The branches of anyOf,
+// oneOf, not and if are not reported, as only the keyword itself failed, and neither are the
+// schemas of missing required properties.
+func schemaResultErrors(field string, result *jsonschema.EvaluationResult, value any) []FieldError {
+	if result.IsValid() {
+		return nil
+	}
+
+	var (
+		errs             []FieldError
+		subresultsFailed bool
+	)
+	for _, detail := range result.Details {
+		if detail.IsValid() || isSchemaBranch(detail.EvaluationPath) {
+			continue
+		}
+		subresultsFailed = true
+		path, detailValue, ok := schemaDetailValue(detail, value)
+		if !ok {
+			continue
+		}
+		errs = append(errs, schemaResultErrors(field+path, detail, detailValue)...)
+	}
+
+	keywords := make([]string, 0, len(result.Errors))
+	for keyword := range result.Errors {
+		if !schemaSubresultKeywords[keyword] || !subresultsFailed {
+			keywords = append(keywords, keyword)
+		}
+	}
+	sort.Strings(keywords)
+	for _, keyword := range keywords {
+		errs = append(errs, FieldError{Field: field, Message: result.Errors[keyword].Error()})
+	}
+	return errs
+}
+
+func isSchemaBranch(evaluationPath string) bool {
+	for _, prefix := range []string{"/anyOf/", "/oneOf/", "/not", "/if"} {
+		if strings.HasPrefix(evaluationPath, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaDetailValue returns the field path, relative to value, and the value a subschema
+// validated: an array item for item schemas, a property otherwise. It fails for a missing
+// property.
+func schemaDetailValue(detail *jsonschema.EvaluationResult, value any) (string, any, bool) {
+	token := strings.TrimPrefix(detail.InstanceLocation, "/")
+	if token == "" {
+		return "", value, true
+	}
+
+	if strings.HasPrefix(detail.EvaluationPath, "/items") || strings.HasPrefix(detail.EvaluationPath, "/prefixItems") {
+		items, _ := value.([]any)
+		index, err := strconv.Atoi(token)
+		if err != nil || index < 0 || index >= len(items) {
+			return "", nil, false
+		}
+		return "[" + token + "]", items[index], true
+	}
+
+	key := strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	properties, _ := value.(map[string]any)
+	property, ok := properties[key]
+	return "." + key, property, ok
+}
+
+var decimalNumber = regexp.MustCompile(`^-?\d+(\.\d+)?([eE][+-]?\d+)?$`)
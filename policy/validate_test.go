@@ -0,0 +1,139 @@
+package policy
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	rtypes "github.com/vultisig/recipes/types"
+	"github.com/vultisig/verifier/types"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func validateFixturePolicy(t *testing.T, configuration map[string]any) types.PluginPolicy {
+	t.Helper()
+	config, err := structpb.NewStruct(configuration)
+	if err != nil {
+		t.Fatalf("failed to build configuration: %v", err)
+	}
+	recipe := &rtypes.Policy{
+		Rules: []*rtypes.Rule{{
+			Resource: "ethereum.eth.transfer",
+			Effect:   rtypes.Effect_EFFECT_ALLOW,
+			ParameterConstraints: []*rtypes.ParameterConstraint{
+				fixedConstraint("recipient", "0x2222222222222222222222222222222222222222"),
+			},
+		}},
+		Configuration: config,
+	}
+	data, err := proto.Marshal(recipe)
+	if err != nil {
+		t.Fatalf("failed to marshal recipe: %v", err)
+	}
+	return types.PluginPolicy{Recipe: base64.StdEncoding.EncodeToString(data)}
+}
+
+func TestValidatePolicyConfiguration(t *testing.T) {
+	configSchema, err := structpb.NewStruct(map[string]any{
+		"type":     "object",
+		"required": []any{"frequency"},
+		"properties": map[string]any{
+			"frequency": map[string]any{
+				"oneOf": []any{
+					map[string]any{"const": "daily"},
+					map[string]any{"const": "weekly"},
+				},
+			},
+			"amount": map[string]any{
+				"anyOf": []any{
+					map[string]any{"type": "string", "format": "integer"},
+					map[string]any{"type": "integer", "minimum": 1},
+				},
+			},
+			"limit": map[string]any{
+				"allOf": []any{
+					map[string]any{"$ref": "#/$defs/positive"},
+					map[string]any{"maximum": 100},
+				},
+			},
+		},
+		"$defs": map[string]any{
+			"positive": map[string]any{"type": "number", "exclusiveMinimum": 0},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to build schema: %v", err)
+	}
+	schema := &rtypes.RecipeSchema{
+		SupportedResources: []*rtypes.ResourcePattern{{
+			ResourcePath: &rtypes.ResourcePath{Full: "ethereum.eth.transfer"},
+			ParameterCapabilities: []*rtypes.ParameterConstraintCapability{{
+				ParameterName:  "recipient",
+				SupportedTypes: rtypes.ConstraintType_CONSTRAINT_TYPE_FIXED,
+				Required:       true,
+			}},
+		}},
+		Configuration: configSchema,
+	}
+
+	tests := []struct {
+		name          string
+		configuration map[string]any
+		wantFields    []string
+	}{
+		{
+			name:          "valid",
+			configuration: map[string]any{"frequency": "weekly", "amount": "1000", "limit": 50},
+		},
+		{
+			name:          "no oneOf branch",
+			configuration: map[string]any{"frequency": "hourly"},
+			wantFields:    []string{"recipe.configuration.frequency"},
+		},
+		{
+			name:          "no anyOf branch",
+			configuration: map[string]any{"frequency": "daily", "amount": 0},
+			wantFields:    []string{"recipe.configuration.amount"},
+		},
+		{
+			name:          "allOf through ref",
+			configuration: map[string]any{"frequency": "daily", "limit": 0},
+			wantFields:    []string{"recipe.configuration.limit"},
+		},
+		{
+			name:          "missing required",
+			configuration: map[string]any{"amount": "1000"},
+			wantFields:    []string{"recipe.configuration"},
+		},
+		{
+			name:          "invalid fee ceilings",
+			configuration: map[string]any{"frequency": "daily", FeeCeilingsKey: map[string]any{"max_total_fee": "1e18"}},
+			wantFields:    []string{"recipe.configuration.fee_ceilings.max_total_fee"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidatePolicy(validateFixturePolicy(t, tc.configuration), schema)
+			if len(tc.wantFields) == 0 {
+				if err != nil {
+					t.Errorf("ValidatePolicy() error = %v", err)
+				}
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("ValidatePolicy() error = %v, want a ValidationError", err)
+			}
+			if len(validationErr.Errors) != len(tc.wantFields) {
+				t.Fatalf("ValidatePolicy() errors = %v, want errors of %v", validationErr.Errors, tc.wantFields)
+			}
+			for i, field := range tc.wantFields {
+				if validationErr.Errors[i].Field != field {
+					t.Errorf("Errors[%d].Field = %s, want %s", i, validationErr.Errors[i].Field, field)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	etypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/vultisig/pluginagent/proposal"
+	abiembed "github.com/vultisig/recipes/abi"
+	"github.com/vultisig/recipes/ethereum"
+	vgcommon "github.com/vultisig/vultisig-go/common"
+)
+
+// erc20ValueMethods are the ERC20 methods that move or allow moving tokens, with the input
+// holding the amount.
+var erc20ValueMethods = map[string]string{
+	"transfer":     "amount",
+	"transferFrom": "amount",
+	"approve":      "amount",
+}
+
+// ErrValueNotSupported is returned for transactions of chains whose value can't be decoded yet.
+var ErrValueNotSupported = errors.New("transaction value is not supported on chain")
+
+// TransactionValues returns the value a transaction moves per token, named like the spend ledger
+// tokens: the lowercase native symbol for native value and the lowercase contract address for
+// ERC20 transfers and approvals. UTXO values are the outputs not paid back to the vault. Other
+// chains return ErrValueNotSupported, so thresholds configured for them can't be skipped.
+func TransactionValues(chain vgcommon.Chain, tx proposal.Tx) (map[string]*big.Int, error) {
+	nativeSymbol, err := chain.NativeSymbol()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get native symbol for chain %s: %w", chain.String(), err)
+	}
+
+	values := make(map[string]*big.Int)
+	switch t := tx.(type) {
+	case *proposal.EvmTx:
+		txData, err := ethereum.DecodeUnsignedPayload(t.PolicyPayload())
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode tx payload: %w", err)
+		}
+		evmTx := etypes.NewTx(txData)
+		if evmTx.Value().Sign() > 0 {
+			values[strings.ToLower(nativeSymbol)] = evmTx.Value()
+		}
+		if evmTx.To() != nil {
+			amount, err := erc20Value(evmTx.Data())
+			if err != nil {
+				return nil, err
+			}
+			if amount != nil && amount.Sign() > 0 {
+				values[strings.ToLower(evmTx.To().Hex())] = amount
+			}
+		}
+	case *proposal.UtxoTx:
+		if value := t.ExternalValue(); value.Sign() > 0 {
+			values[strings.ToLower(nativeSymbol)] = value
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrValueNotSupported, chain.String())
+	}
+	return values, nil
+}
+
+// erc20Value returns the amount of an ERC20 call moving tokens, or nil for any other call data.
+func erc20Value(data []byte) (*big.Int, error) {
+	const selectorSize = 4
+	if len(data) < selectorSize {
+		return nil, nil
+	}
+
+	file, err := abiembed.Dir.Open("erc20.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open erc20 abi: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	erc20Abi, err := abi.JSON(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse erc20 abi: %w", err)
+	}
+
+	method, err := erc20Abi.MethodById(data[:selectorSize])
+	if err != nil {
+		return nil, nil
+	}
+	parameter, ok := erc20ValueMethods[method.Name]
+	if !ok {
+		return nil, nil
+	}
+	args, err := method.Inputs.Unpack(data[selectorSize:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack %s args: %w", method.Name, err)
+	}
+	for i, input := range method.Inputs {
+		if input.Name != parameter {
+			continue
+		}
+		amount, ok := args[i].(*big.Int)
+		if !ok {
+			return nil, fmt.Errorf("parameter %s is not an integer", parameter)
+		}
+		return amount, nil
+	}
+	return nil, fmt.Errorf("parameter %s not found in %s", parameter, method.Name)
+}
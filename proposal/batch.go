@@ -0,0 +1,80 @@
+package proposal
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+
+	vgcommon "github.com/vultisig/vultisig-go/common"
+)
+
+// MaxBatchSize caps the transactions of a batch, all of them are signed in one keysign session.
+const MaxBatchSize = 16
+
+// Batch is a set of transactions of one chain that is validated as a unit and signed in a single
+// keysign session, e.g. an ERC20 approve followed by the swap spending the allowance.
+type Batch struct {
+	Chain vgcommon.Chain
+	Txs   []Tx
+}
+
+// DecodeBatch decodes the payloads of a batch in order. EVM transactions of a batch must use
+// consecutive nonces, so they can only be mined in the order they were proposed.
+func DecodeBatch(chain vgcommon.Chain, payloads [][]byte) (*Batch, error) {
+	if len(payloads) < 2 || len(payloads) > MaxBatchSize {
+		return nil, fmt.Errorf("a batch needs between 2 and %d transactions, got %d", MaxBatchSize, len(payloads))
+	}
+
+	txs := make([]Tx, 0, len(payloads))
+	for i, payload := range payloads {
+		tx, err := DecodeTx(chain, payload)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		txs = append(txs, tx)
+	}
+
+	if chain.IsEvm() {
+		first := txs[0].(*EvmTx).Tx.Nonce()
+		for i, tx := range txs[1:] {
+			if nonce := tx.(*EvmTx).Tx.Nonce(); nonce != first+uint64(i+1) {
+				return nil, fmt.Errorf("transaction %d has nonce %d, expected %d", i+1, nonce, first+uint64(i+1))
+			}
+		}
+	}
+
+	return &Batch{
+		Chain: chain,
+		Txs:   txs,
+	}, nil
+}
+
+// Digest identifies the batch by the digests of its transactions, in order.
+func (b *Batch) Digest() string {
+	h := sha256.New()
+	for _, tx := range b.Txs {
+		h.Write([]byte(tx.Digest()))
+		h.Write([]byte{0})
+	}
+	return "batch:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// KeysignMessageHashes returns the hashes the keysign messages of the transaction are keyed by,
+// which are the keys of its signatures, in keysign message order.
+func KeysignMessageHashes(tx Tx) ([]string, error) {
+	hashes, err := tx.SigningHashes()
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(hashes))
+	for _, hash := range hashes {
+		keys = append(keys, keysignMessageHash(hash))
+	}
+	return keys, nil
+}
+
+func keysignMessageHash(hash []byte) string {
+	msgHash := sha256.Sum256(hash)
+	return base64.StdEncoding.EncodeToString(msgHash[:])
+}
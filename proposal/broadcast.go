@@ -0,0 +1,62 @@
+package proposal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	vgcommon "github.com/vultisig/vultisig-go/common"
+)
+
+// Broadcaster submits signed transactions to a chain and returns the resulting transaction hash.
+type Broadcaster interface {
+	Broadcast(ctx context.Context, chain vgcommon.Chain, signedTx []byte) (string, error)
+}
+
+var _ Broadcaster = (*EvmBroadcaster)(nil)
+
+// EvmBroadcaster sends raw transactions through eth_sendRawTransaction on the configured chain RPC.
+type EvmBroadcaster struct {
+	clients map[vgcommon.Chain]*rpc.Client
+}
+
+func NewEvmBroadcaster(endpoints map[string]string) (*EvmBroadcaster, error) {
+	clients, err := dialEvmClients(endpoints)
+	if err != nil {
+		return nil, err
+	}
+	return &EvmBroadcaster{
+		clients: clients,
+	}, nil
+}
+
+func (b *EvmBroadcaster) Broadcast(ctx context.Context, chain vgcommon.Chain, signedTx []byte) (string, error) {
+	client, ok := b.clients[chain]
+	if !ok {
+		return "", fmt.Errorf("no rpc endpoint configured for chain %s", chain.String())
+	}
+
+	var txHash string
+	if err := client.CallContext(ctx, &txHash, "eth_sendRawTransaction", hexutil.Encode(signedTx)); err != nil {
+		// The node already holds the transaction, e.g. from an earlier attempt, so it is broadcast
+		if isAlreadyKnown(err) {
+			var tx gtypes.Transaction
+			if er := tx.UnmarshalBinary(signedTx); er != nil {
+				return "", fmt.Errorf("failed to decode signed transaction: %w", er)
+			}
+			return tx.Hash().Hex(), nil
+		}
+		return "", fmt.Errorf("failed to send raw transaction: %w", err)
+	}
+	return txHash, nil
+}
+
+// isAlreadyKnown reports whether the node rejected the transaction because it already has it,
+// geth answers "already known", older nodes "known transaction".
+func isAlreadyKnown(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "already known") || strings.Contains(msg, "known transaction")
+}
@@ -0,0 +1,121 @@
+package proposal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gtypes "github.com/ethereum/go-ethereum/core/types"
+	vgcommon "github.com/vultisig/vultisig-go/common"
+)
+
+type rpcRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params []string        `json:"params"`
+}
+
+// newRPCServer answers eth_sendRawTransaction with the result or the error message.
+func newRPCServer(t *testing.T, result string, errMsg string) (*httptest.Server, *[]rpcRequest) {
+	t.Helper()
+	var received []rpcRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode rpc request: %v", err)
+			return
+		}
+		received = append(received, req)
+
+		resp := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+		if errMsg != "" {
+			resp["error"] = map[string]any{"code": -32000, "message": errMsg}
+		} else {
+			resp["result"] = result
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &received
+}
+
+func TestEvmBroadcaster(t *testing.T) {
+	// The signed EIP-1559 fixture of TestDecodeEvmTx
+	signedTx := mustDecodeHex(t, evmFixtures[2].signed)
+	var tx gtypes.Transaction
+	if err := tx.UnmarshalBinary(signedTx); err != nil {
+		t.Fatalf("failed to decode signed fixture: %v", err)
+	}
+	txHash := tx.Hash().Hex()
+
+	tests := []struct {
+		name     string
+		result   string
+		rpcError string
+		wantHash string
+		wantErr  string
+	}{
+		{
+			name:     "success",
+			result:   txHash,
+			wantHash: txHash,
+		},
+		{
+			name:     "rpc error",
+			rpcError: "insufficient funds for gas * price + value",
+			wantErr:  "insufficient funds",
+		},
+		{
+			// Nodes that already hold the transaction don't return its hash
+			name:     "already known",
+			rpcError: "already known",
+			wantHash: txHash,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, received := newRPCServer(t, tc.result, tc.rpcError)
+			broadcaster, err := NewEvmBroadcaster(map[string]string{"ethereum": srv.URL})
+			if err != nil {
+				t.Fatalf("NewEvmBroadcaster() error = %v", err)
+			}
+
+			hash, err := broadcaster.Broadcast(context.Background(), vgcommon.Ethereum, signedTx)
+			if len(*received) != 1 {
+				t.Fatalf("rpc received %d requests, want 1", len(*received))
+			}
+			req := (*received)[0]
+			if req.Method != "eth_sendRawTransaction" || len(req.Params) != 1 || req.Params[0] != hexutil.Encode(signedTx) {
+				t.Errorf("rpc request = %s %v, want eth_sendRawTransaction of the signed tx", req.Method, req.Params)
+			}
+
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("Broadcast() error = %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Broadcast() error = %v", err)
+			}
+			if hash != tc.wantHash {
+				t.Errorf("Broadcast() = %s, want %s", hash, tc.wantHash)
+			}
+		})
+	}
+}
+
+func TestEvmBroadcasterUnconfiguredChain(t *testing.T) {
+	broadcaster, err := NewEvmBroadcaster(map[string]string{})
+	if err != nil {
+		t.Fatalf("NewEvmBroadcaster() error = %v", err)
+	}
+	if _, err := broadcaster.Broadcast(context.Background(), vgcommon.Arbitrum, []byte{0x02}); err == nil {
+		t.Error("Broadcast() succeeded without an rpc endpoint")
+	}
+}
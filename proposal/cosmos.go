@@ -0,0 +1,373 @@
+package proposal
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	secp256k1v1 "cosmossdk.io/api/cosmos/crypto/secp256k1"
+	signingv1beta1 "cosmossdk.io/api/cosmos/tx/signing/v1beta1"
+	txv1beta1 "cosmossdk.io/api/cosmos/tx/v1beta1"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/vultisig/mobile-tss-lib/tss"
+	vgcommon "github.com/vultisig/vultisig-go/common"
+	"google.golang.org/protobuf/proto"
+)
+
+type CosmosSignMode string
+
+const (
+	CosmosSignModeDirect    CosmosSignMode = "direct"
+	CosmosSignModeAminoJSON CosmosSignMode = "amino_json"
+
+	TxTypeCosmosDirect    TxType = "cosmos_direct"
+	TxTypeCosmosAminoJSON TxType = "cosmos_amino_json"
+
+	cosmosSecp256k1PubKeyType = "/cosmos.crypto.secp256k1.PubKey"
+)
+
+// cosmosChains are the Cosmos SDK chains whose keys are secp256k1 and derived through the
+// vgcommon chain paths, with the denom of their native fee token.
+var cosmosChains = map[vgcommon.Chain]string{
+	vgcommon.THORChain:    "rune",
+	vgcommon.MayaChain:    "cacao",
+	vgcommon.GaiaChain:    "uatom",
+	vgcommon.Kujira:       "ukuji",
+	vgcommon.Dydx:         "adydx",
+	vgcommon.Osmosis:      "uosmo",
+	vgcommon.Noble:        "uusdc",
+	vgcommon.Terra:        "uluna",
+	vgcommon.TerraClassic: "uluna",
+}
+
+func IsCosmosChain(chain vgcommon.Chain) bool {
+	_, ok := cosmosChains[chain]
+	return ok
+}
+
+// CosmosPayload is what plugins propose on Cosmos SDK chains. For direct mode SignDoc is the
+// protobuf SignDoc, which already carries the body and auth info. For amino_json mode SignDoc is
+// the StdSignDoc JSON, and the body and auth info have to be sent along since the TxRaw can't be
+// rebuilt from the JSON.
+type CosmosPayload struct {
+	SignMode      CosmosSignMode `json:"sign_mode"`
+	ChainID       string         `json:"chain_id"`
+	SignDoc       []byte         `json:"sign_doc"`
+	BodyBytes     []byte         `json:"body_bytes,omitempty"`
+	AuthInfoBytes []byte         `json:"auth_info_bytes,omitempty"`
+}
+
+// CosmosMessage is a transaction message as seen by the policy engine. Data is the hex encoded
+// protobuf value in direct mode, and the compact JSON value in amino_json mode.
+type CosmosMessage struct {
+	Type string
+	Data string
+}
+
+// CosmosCoin is an amount of a denom, in its base unit.
+type CosmosCoin struct {
+	Denom  string
+	Amount *big.Int
+}
+
+// CosmosTx is a SignDoc proposed on a Cosmos SDK chain. It must have a single secp256k1 signer.
+// Fee and GasLimit are taken from the auth info, which the signature commits to in both modes.
+type CosmosTx struct {
+	Chain     vgcommon.Chain
+	Payload   CosmosPayload
+	Messages  []CosmosMessage
+	Memo      string
+	Fee       []CosmosCoin
+	GasLimit  uint64
+	SignBytes []byte
+	SignerKey []byte
+	Raw       []byte
+}
+
+// ParseCosmosPayload decodes and cross checks the payload without binding it to a chain.
+func ParseCosmosPayload(payload []byte) (*CosmosTx, error) {
+	var p CosmosPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return nil, fmt.Errorf("failed to decode cosmos payload: %w", err)
+	}
+	if p.ChainID == "" {
+		return nil, errors.New("chain_id is required")
+	}
+
+	t := &CosmosTx{
+		Payload: p,
+		Raw:     payload,
+	}
+
+	var expectedMode signingv1beta1.SignMode
+	switch p.SignMode {
+	case CosmosSignModeDirect:
+		expectedMode = signingv1beta1.SignMode_SIGN_MODE_DIRECT
+		if err := t.decodeDirect(); err != nil {
+			return nil, err
+		}
+	case CosmosSignModeAminoJSON:
+		expectedMode = signingv1beta1.SignMode_SIGN_MODE_LEGACY_AMINO_JSON
+		if err := t.decodeAminoJSON(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported sign mode %q", p.SignMode)
+	}
+
+	var authInfo txv1beta1.AuthInfo
+	if err := proto.Unmarshal(t.Payload.AuthInfoBytes, &authInfo); err != nil {
+		return nil, fmt.Errorf("failed to decode auth info: %w", err)
+	}
+	signerKey, err := decodeCosmosSigner(&authInfo, expectedMode)
+	if err != nil {
+		return nil, err
+	}
+	t.SignerKey = signerKey
+
+	for _, coin := range authInfo.GetFee().GetAmount() {
+		amount, ok := new(big.Int).SetString(coin.Amount, 10)
+		if !ok || amount.Sign() < 0 {
+			return nil, fmt.Errorf("invalid fee amount %q of %s", coin.Amount, coin.Denom)
+		}
+		t.Fee = append(t.Fee, CosmosCoin{Denom: coin.Denom, Amount: amount})
+	}
+	t.GasLimit = authInfo.GetFee().GetGasLimit()
+
+	return t, nil
+}
+
+func (t *CosmosTx) decodeDirect() error {
+	var signDoc txv1beta1.SignDoc
+	if err := proto.Unmarshal(t.Payload.SignDoc, &signDoc); err != nil {
+		return fmt.Errorf("failed to decode sign doc: %w", err)
+	}
+	if signDoc.ChainId != t.Payload.ChainID {
+		return fmt.Errorf("sign doc chain ID %q does not match %q", signDoc.ChainId, t.Payload.ChainID)
+	}
+	if len(t.Payload.BodyBytes) != 0 || len(t.Payload.AuthInfoBytes) != 0 {
+		return errors.New("body_bytes and auth_info_bytes are taken from the sign doc in direct mode")
+	}
+
+	var body txv1beta1.TxBody
+	if err := proto.Unmarshal(signDoc.BodyBytes, &body); err != nil {
+		return fmt.Errorf("failed to decode tx body: %w", err)
+	}
+	for _, msg := range body.Messages {
+		t.Messages = append(t.Messages, CosmosMessage{
+			Type: msg.TypeUrl,
+			Data: hex.EncodeToString(msg.Value),
+		})
+	}
+	t.Memo = body.Memo
+
+	// The chain verifies against the SignDoc it rebuilds from the TxRaw, so sign the
+	// deterministic encoding rather than whatever bytes were submitted
+	signBytes, err := proto.MarshalOptions{Deterministic: true}.Marshal(&signDoc)
+	if err != nil {
+		return fmt.Errorf("failed to encode sign doc: %w", err)
+	}
+	t.SignBytes = signBytes
+	t.Payload.BodyBytes = signDoc.BodyBytes
+	t.Payload.AuthInfoBytes = signDoc.AuthInfoBytes
+	return nil
+}
+
+type aminoSignDoc struct {
+	ChainID string `json:"chain_id"`
+	Memo    string `json:"memo"`
+	Msgs    []struct {
+		Type  string          `json:"type"`
+		Value json.RawMessage `json:"value"`
+	} `json:"msgs"`
+}
+
+func (t *CosmosTx) decodeAminoJSON() error {
+	if len(t.Payload.BodyBytes) == 0 || len(t.Payload.AuthInfoBytes) == 0 {
+		return errors.New("body_bytes and auth_info_bytes are required in amino_json mode")
+	}
+
+	// Amino sign bytes are the JSON with sorted keys, the same encoding the SDK produces
+	var doc any
+	decoder := json.NewDecoder(bytes.NewReader(t.Payload.SignDoc))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return fmt.Errorf("failed to decode amino sign doc: %w", err)
+	}
+	signBytes, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode amino sign doc: %w", err)
+	}
+
+	var signDoc aminoSignDoc
+	if err := json.Unmarshal(signBytes, &signDoc); err != nil {
+		return fmt.Errorf("failed to decode amino sign doc: %w", err)
+	}
+	if signDoc.ChainID != t.Payload.ChainID {
+		return fmt.Errorf("sign doc chain ID %q does not match %q", signDoc.ChainID, t.Payload.ChainID)
+	}
+
+	var body txv1beta1.TxBody
+	if err := proto.Unmarshal(t.Payload.BodyBytes, &body); err != nil {
+		return fmt.Errorf("failed to decode tx body: %w", err)
+	}
+	if len(body.Messages) != len(signDoc.Msgs) || body.Memo != signDoc.Memo {
+		return errors.New("tx body does not match the amino sign doc")
+	}
+
+	for _, msg := range signDoc.Msgs {
+		var value bytes.Buffer
+		if err := json.Compact(&value, msg.Value); err != nil {
+			return fmt.Errorf("failed to encode amino message value: %w", err)
+		}
+		t.Messages = append(t.Messages, CosmosMessage{
+			Type: msg.Type,
+			Data: value.String(),
+		})
+	}
+	t.Memo = signDoc.Memo
+	t.SignBytes = signBytes
+	return nil
+}
+
+// decodeCosmosSigner returns the compressed public key of the single signer of the auth info.
+func decodeCosmosSigner(authInfo *txv1beta1.AuthInfo, expectedMode signingv1beta1.SignMode) ([]byte, error) {
+	if len(authInfo.SignerInfos) != 1 {
+		return nil, fmt.Errorf("expected a single signer, got %d", len(authInfo.SignerInfos))
+	}
+
+	signerInfo := authInfo.SignerInfos[0]
+	if mode := signerInfo.GetModeInfo().GetSingle().GetMode(); mode != expectedMode {
+		return nil, fmt.Errorf("signer mode %s does not match %s", mode.String(), expectedMode.String())
+	}
+	if signerInfo.PublicKey == nil || signerInfo.PublicKey.TypeUrl != cosmosSecp256k1PubKeyType {
+		return nil, errors.New("signer public key must be a secp256k1 key")
+	}
+
+	var pubKey secp256k1v1.PubKey
+	if err := proto.Unmarshal(signerInfo.PublicKey.Value, &pubKey); err != nil {
+		return nil, fmt.Errorf("failed to decode signer public key: %w", err)
+	}
+	return pubKey.Key, nil
+}
+
+func DecodeCosmosTx(chain vgcommon.Chain, payload []byte) (*CosmosTx, error) {
+	if !IsCosmosChain(chain) {
+		return nil, fmt.Errorf("chain %s is not a supported Cosmos chain", chain.String())
+	}
+
+	t, err := ParseCosmosPayload(payload)
+	if err != nil {
+		return nil, err
+	}
+	t.Chain = chain
+	return t, nil
+}
+
+// NativeFee returns the fee in the native fee token of the chain, or false if the fee is paid in
+// any other denom.
+func (t *CosmosTx) NativeFee() (*big.Int, bool) {
+	total := new(big.Int)
+	for _, coin := range t.Fee {
+		if coin.Denom != cosmosChains[t.Chain] {
+			return nil, false
+		}
+		total.Add(total, coin.Amount)
+	}
+	return total, true
+}
+
+func (t *CosmosTx) Type() TxType {
+	if t.Payload.SignMode == CosmosSignModeAminoJSON {
+		return TxTypeCosmosAminoJSON
+	}
+	return TxTypeCosmosDirect
+}
+
+func (t *CosmosTx) PolicyPayload() []byte {
+	return t.Raw
+}
+
+func (t *CosmosTx) signingHash() []byte {
+	hash := sha256.Sum256(t.SignBytes)
+	return hash[:]
+}
+
+func (t *CosmosTx) Digest() string {
+	return hex.EncodeToString(t.signingHash())
+}
+
+// SigningHashes returns the SHA-256 of the sign bytes, which is what Cosmos secp256k1 keys sign.
+func (t *CosmosTx) SigningHashes() ([][]byte, error) {
+	return [][]byte{t.signingHash()}, nil
+}
+
+// CheckSigner verifies that the auth info signer is the given compressed public key.
+func (t *CosmosTx) CheckSigner(pubKey []byte) error {
+	if !bytes.Equal(t.SignerKey, pubKey) {
+		return fmt.Errorf(
+			"signer public key %s is not the vault key %s",
+			hex.EncodeToString(t.SignerKey),
+			hex.EncodeToString(pubKey),
+		)
+	}
+	return nil
+}
+
+// Assemble verifies the signature, normalizes it to low S as the SDK requires and returns the
+// serialized TxRaw with its transaction hash.
+func (t *CosmosTx) Assemble(sig tss.KeysignResponse, pubKey []byte) ([]byte, string, error) {
+	if err := t.CheckSigner(pubKey); err != nil {
+		return nil, "", err
+	}
+
+	key, err := btcec.ParsePubKey(pubKey)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid vault public key: %w", err)
+	}
+
+	rBytes, err := decodeSignatureComponent(sig.R, 32)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid signature R: %w", err)
+	}
+	sBytes, err := decodeSignatureComponent(sig.S, 32)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid signature S: %w", err)
+	}
+	var r, s btcec.ModNScalar
+	if overflow := r.SetByteSlice(rBytes); overflow || r.IsZero() {
+		return nil, "", errors.New("signature R is out of range")
+	}
+	if overflow := s.SetByteSlice(sBytes); overflow || s.IsZero() {
+		return nil, "", errors.New("signature S is out of range")
+	}
+	if s.IsOverHalfOrder() {
+		s.Negate()
+	}
+	if !ecdsa.NewSignature(&r, &s).Verify(t.signingHash(), key) {
+		return nil, "", errors.New("signature does not verify against the vault public key")
+	}
+
+	rOut, sOut := r.Bytes(), s.Bytes()
+	signature := make([]byte, 0, 64)
+	signature = append(signature, rOut[:]...)
+	signature = append(signature, sOut[:]...)
+
+	txRaw, err := proto.MarshalOptions{Deterministic: true}.Marshal(&txv1beta1.TxRaw{
+		BodyBytes:     t.Payload.BodyBytes,
+		AuthInfoBytes: t.Payload.AuthInfoBytes,
+		Signatures:    [][]byte{signature},
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode tx raw: %w", err)
+	}
+
+	txHash := sha256.Sum256(txRaw)
+	return txRaw, strings.ToUpper(hex.EncodeToString(txHash[:])), nil
+}
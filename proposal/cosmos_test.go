@@ -0,0 +1,270 @@
+package proposal
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/vultisig/mobile-tss-lib/tss"
+	vgcommon "github.com/vultisig/vultisig-go/common"
+)
+
+// The fixtures send 1000uatom from cosmos1from to cosmos1to with memo "fixture", a fee of
+// 5000uatom and 200000 gas, signed by the key sha256("cosmos fixture").
+const (
+	cosmosFixtureSigner   = "038bfaf1cb696423a6bb2d5f734b28dde356200b8f9b79d766df5d3e23feeecf6b"
+	cosmosFixtureBody     = "0a470a1c2f636f736d6f732e62616e6b2e763162657461312e4d736753656e6412270a0b636f736d6f733166726f6d1209636f736d6f7331746f1a0d0a057561746f6d120431303030120766697874757265"
+	cosmosFixtureMsgSend  = "0a0b636f736d6f733166726f6d1209636f736d6f7331746f1a0d0a057561746f6d120431303030"
+	cosmosFixtureDirectAI = "0a500a460a1f2f636f736d6f732e63727970746f2e736563703235366b312e5075624b657912230a21038bfaf1cb696423a6bb2d5f734b28dde356200b8f9b79d766df5d3e23feeecf6b12040a020801180712130a0d0a057561746f6d12043530303010c09a0c"
+	cosmosFixtureAminoAI  = "0a500a460a1f2f636f736d6f732e63727970746f2e736563703235366b312e5075624b657912230a21038bfaf1cb696423a6bb2d5f734b28dde356200b8f9b79d766df5d3e23feeecf6b12040a02087f180712130a0d0a057561746f6d12043530303010c09a0c"
+	cosmosFixtureSignDoc  = "0a520a470a1c2f636f736d6f732e62616e6b2e763162657461312e4d736753656e6412270a0b636f736d6f733166726f6d1209636f736d6f7331746f1a0d0a057561746f6d12043130303012076669787475726512670a500a460a1f2f636f736d6f732e63727970746f2e736563703235366b312e5075624b657912230a21038bfaf1cb696423a6bb2d5f734b28dde356200b8f9b79d766df5d3e23feeecf6b12040a020801180712130a0d0a057561746f6d12043530303010c09a0c1a0b636f736d6f736875622d34202a"
+
+	// The amino doc as a wallet would submit it, and the sorted compact JSON the SDK signs
+	cosmosFixtureAminoDoc = `{
+		"chain_id": "cosmoshub-4",
+		"account_number": "42",
+		"sequence": "7",
+		"fee": {"gas": "200000", "amount": [{"denom": "uatom", "amount": "5000"}]},
+		"msgs": [{
+			"type": "cosmos-sdk/MsgSend",
+			"value": {"from_address": "cosmos1from", "to_address": "cosmos1to", "amount": [{"denom": "uatom", "amount": "1000"}]}
+		}],
+		"memo": "fixture"
+	}`
+	cosmosFixtureAminoSignBytes = `{"account_number":"42","chain_id":"cosmoshub-4","fee":{"amount":[{"amount":"5000","denom":"uatom"}],"gas":"200000"},"memo":"fixture","msgs":[{"type":"cosmos-sdk/MsgSend","value":{"amount":[{"amount":"1000","denom":"uatom"}],"from_address":"cosmos1from","to_address":"cosmos1to"}}],"sequence":"7"}`
+)
+
+func cosmosDirectPayload(t *testing.T) []byte {
+	t.Helper()
+	payload, err := json.Marshal(CosmosPayload{
+		SignMode: CosmosSignModeDirect,
+		ChainID:  "cosmoshub-4",
+		SignDoc:  mustDecodeHex(t, cosmosFixtureSignDoc),
+	})
+	if err != nil {
+		t.Fatalf("failed to encode payload: %v", err)
+	}
+	return payload
+}
+
+func cosmosAminoPayload(t *testing.T) []byte {
+	t.Helper()
+	payload, err := json.Marshal(CosmosPayload{
+		SignMode:      CosmosSignModeAminoJSON,
+		ChainID:       "cosmoshub-4",
+		SignDoc:       []byte(cosmosFixtureAminoDoc),
+		BodyBytes:     mustDecodeHex(t, cosmosFixtureBody),
+		AuthInfoBytes: mustDecodeHex(t, cosmosFixtureAminoAI),
+	})
+	if err != nil {
+		t.Fatalf("failed to encode payload: %v", err)
+	}
+	return payload
+}
+
+func TestDecodeCosmosTx(t *testing.T) {
+	tests := []struct {
+		name        string
+		payload     func(t *testing.T) []byte
+		txType      TxType
+		signBytes   string
+		digest      string
+		messageType string
+		messageData string
+	}{
+		{
+			name:        "direct",
+			payload:     cosmosDirectPayload,
+			txType:      TxTypeCosmosDirect,
+			signBytes:   cosmosFixtureSignDoc,
+			digest:      "a30db27da3df34db0e15e2adfc4b2e8685ee1d0959377cbaf452b75ec2d89436",
+			messageType: "/cosmos.bank.v1beta1.MsgSend",
+			messageData: cosmosFixtureMsgSend,
+		},
+		{
+			name:        "amino json",
+			payload:     cosmosAminoPayload,
+			txType:      TxTypeCosmosAminoJSON,
+			signBytes:   hex.EncodeToString([]byte(cosmosFixtureAminoSignBytes)),
+			digest:      "60f6572e210427a27b54118c6257864e35077325108990639a2e994422750497",
+			messageType: "cosmos-sdk/MsgSend",
+			messageData: `{"amount":[{"amount":"1000","denom":"uatom"}],"from_address":"cosmos1from","to_address":"cosmos1to"}`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tx, err := DecodeCosmosTx(vgcommon.GaiaChain, tc.payload(t))
+			if err != nil {
+				t.Fatalf("DecodeCosmosTx() error = %v", err)
+			}
+			if tx.Type() != tc.txType {
+				t.Errorf("Type() = %s, want %s", tx.Type(), tc.txType)
+			}
+			if got := hex.EncodeToString(tx.SignBytes); got != tc.signBytes {
+				t.Errorf("SignBytes = %s, want %s", got, tc.signBytes)
+			}
+			if got := tx.Digest(); got != tc.digest {
+				t.Errorf("Digest() = %s, want %s", got, tc.digest)
+			}
+			if len(tx.Messages) != 1 || tx.Messages[0].Type != tc.messageType || tx.Messages[0].Data != tc.messageData {
+				t.Errorf("Messages = %+v, want a single %s message with %s", tx.Messages, tc.messageType, tc.messageData)
+			}
+			if tx.Memo != "fixture" {
+				t.Errorf("Memo = %q, want %q", tx.Memo, "fixture")
+			}
+			if err := tx.CheckSigner(mustDecodeHex(t, cosmosFixtureSigner)); err != nil {
+				t.Errorf("CheckSigner() error = %v", err)
+			}
+			if len(tx.Fee) != 1 || tx.Fee[0].Denom != "uatom" || tx.Fee[0].Amount.String() != "5000" || tx.GasLimit != 200000 {
+				t.Errorf("Fee = %+v gas %d, want 5000uatom and 200000 gas", tx.Fee, tx.GasLimit)
+			}
+		})
+	}
+}
+
+func TestCosmosTransactionFee(t *testing.T) {
+	tx, err := DecodeCosmosTx(vgcommon.GaiaChain, cosmosDirectPayload(t))
+	if err != nil {
+		t.Fatalf("DecodeCosmosTx() error = %v", err)
+	}
+	fee, ok := TransactionFee(tx)
+	if !ok {
+		t.Fatal("TransactionFee() did not return the fee")
+	}
+	if fee.GasLimit != 200000 || fee.FeePerGas != nil || fee.Total.String() != "5000" {
+		t.Errorf("TransactionFee() = %+v, want a total of 5000 and 200000 gas", fee)
+	}
+
+	// uatom is not the fee token of osmosis, so the fee can't be compared to its ceilings
+	tx, err = DecodeCosmosTx(vgcommon.Osmosis, cosmosDirectPayload(t))
+	if err != nil {
+		t.Fatalf("DecodeCosmosTx() error = %v", err)
+	}
+	if _, ok := TransactionFee(tx); ok {
+		t.Error("TransactionFee() returned a fee paid in a foreign denom")
+	}
+}
+
+func TestDecodeCosmosTxRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		chain   vgcommon.Chain
+		payload CosmosPayload
+	}{
+		{
+			name:  "not a cosmos chain",
+			chain: vgcommon.Ethereum,
+			payload: CosmosPayload{
+				SignMode: CosmosSignModeDirect,
+				ChainID:  "cosmoshub-4",
+				SignDoc:  mustDecodeHex(t, cosmosFixtureSignDoc),
+			},
+		},
+		{
+			name:  "chain ID mismatch",
+			chain: vgcommon.GaiaChain,
+			payload: CosmosPayload{
+				SignMode: CosmosSignModeDirect,
+				ChainID:  "osmosis-1",
+				SignDoc:  mustDecodeHex(t, cosmosFixtureSignDoc),
+			},
+		},
+		{
+			name:  "direct with body bytes",
+			chain: vgcommon.GaiaChain,
+			payload: CosmosPayload{
+				SignMode:  CosmosSignModeDirect,
+				ChainID:   "cosmoshub-4",
+				SignDoc:   mustDecodeHex(t, cosmosFixtureSignDoc),
+				BodyBytes: mustDecodeHex(t, cosmosFixtureBody),
+			},
+		},
+		{
+			name:  "amino without body",
+			chain: vgcommon.GaiaChain,
+			payload: CosmosPayload{
+				SignMode: CosmosSignModeAminoJSON,
+				ChainID:  "cosmoshub-4",
+				SignDoc:  []byte(cosmosFixtureAminoDoc),
+			},
+		},
+		{
+			name:  "amino signer in direct mode",
+			chain: vgcommon.GaiaChain,
+			payload: CosmosPayload{
+				SignMode:      CosmosSignModeAminoJSON,
+				ChainID:       "cosmoshub-4",
+				SignDoc:       []byte(cosmosFixtureAminoDoc),
+				BodyBytes:     mustDecodeHex(t, cosmosFixtureBody),
+				AuthInfoBytes: mustDecodeHex(t, cosmosFixtureDirectAI),
+			},
+		},
+		{
+			name:  "amino memo mismatch",
+			chain: vgcommon.GaiaChain,
+			payload: CosmosPayload{
+				SignMode:      CosmosSignModeAminoJSON,
+				ChainID:       "cosmoshub-4",
+				SignDoc:       []byte(`{"chain_id":"cosmoshub-4","memo":"other","msgs":[{"type":"cosmos-sdk/MsgSend","value":{}}]}`),
+				BodyBytes:     mustDecodeHex(t, cosmosFixtureBody),
+				AuthInfoBytes: mustDecodeHex(t, cosmosFixtureAminoAI),
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			payload, err := json.Marshal(tc.payload)
+			if err != nil {
+				t.Fatalf("failed to encode payload: %v", err)
+			}
+			if _, err := DecodeCosmosTx(tc.chain, payload); err == nil {
+				t.Error("DecodeCosmosTx() accepted an invalid payload")
+			}
+		})
+	}
+}
+
+func TestCosmosAssemble(t *testing.T) {
+	tx, err := DecodeCosmosTx(vgcommon.GaiaChain, cosmosDirectPayload(t))
+	if err != nil {
+		t.Fatalf("DecodeCosmosTx() error = %v", err)
+	}
+	signer := mustDecodeHex(t, cosmosFixtureSigner)
+
+	// RFC 6979 signature of the direct digest, already low S
+	const r = "b2a743bcacdc885b9ba166ac87e4d043e7eb666caa23d9cc6f0b9e16b470d5b4"
+	const s = "43437b99a462a6ece9a15c26b34d971eea504660fcef98ca7f824ad75d4136b2"
+	const txHash = "60EDA020B6713F7070BE4C3B7C22B2BE699E9C23A3E01E12A9FBC70F57582025"
+
+	var highS btcec.ModNScalar
+	highS.SetByteSlice(mustDecodeHex(t, s))
+	highS.Negate()
+	highSBytes := highS.Bytes()
+
+	for name, sValue := range map[string]string{
+		"low s":  s,
+		"high s": hex.EncodeToString(highSBytes[:]),
+	} {
+		t.Run(name, func(t *testing.T) {
+			txRaw, hash, err := tx.Assemble(tss.KeysignResponse{R: r, S: sValue}, signer)
+			if err != nil {
+				t.Fatalf("Assemble() error = %v", err)
+			}
+			if hash != txHash {
+				t.Errorf("Assemble() hash = %s, want %s", hash, txHash)
+			}
+			if len(txRaw) == 0 {
+				t.Error("Assemble() returned an empty tx")
+			}
+		})
+	}
+
+	otherKey := mustDecodeHex(t, "02"+cosmosFixtureSigner[2:])
+	if _, _, err := tx.Assemble(tss.KeysignResponse{R: r, S: s}, otherKey); err == nil {
+		t.Error("Assemble() accepted a key that is not the signer")
+	}
+	if _, _, err := tx.Assemble(tss.KeysignResponse{R: s, S: r}, signer); err == nil {
+		t.Error("Assemble() accepted an invalid signature")
+	}
+}
@@ -0,0 +1,158 @@
+package proposal
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	gtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/vultisig/mobile-tss-lib/tss"
+	"github.com/vultisig/recipes/ethereum"
+	vgcommon "github.com/vultisig/vultisig-go/common"
+)
+
+const (
+	EvmTxTypeLegacy     TxType = "legacy"
+	EvmTxTypeAccessList TxType = "access_list"
+	EvmTxTypeDynamicFee TxType = "dynamic_fee"
+)
+
+// EvmTx is an unsigned EVM transaction as submitted by plugins, i.e. the type byte
+// followed by the RLP encoded fields without the signature values.
+type EvmTx struct {
+	Chain   vgcommon.Chain
+	ChainID *big.Int
+	Tx      *gtypes.Transaction
+	Raw     []byte
+}
+
+// DecodeEvmTx decodes an unsigned legacy, EIP-2930 or EIP-1559 transaction for the given chain.
+// Typed transactions carry their own chain ID, which must match the chain they are proposed on.
+func DecodeEvmTx(chain vgcommon.Chain, payload []byte) (*EvmTx, error) {
+	chainID, err := chain.EvmID()
+	if err != nil {
+		return nil, fmt.Errorf("chain %s is not an EVM chain: %w", chain.String(), err)
+	}
+
+	txData, err := ethereum.DecodeUnsignedPayload(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode unsigned transaction: %w", err)
+	}
+	tx := gtypes.NewTx(txData)
+
+	if tx.Type() != gtypes.LegacyTxType && tx.ChainId().Cmp(chainID) != 0 {
+		return nil, fmt.Errorf(
+			"transaction chain ID %s does not match chain %s (%s)",
+			tx.ChainId().String(),
+			chain.String(),
+			chainID.String(),
+		)
+	}
+
+	return &EvmTx{
+		Chain:   chain,
+		ChainID: chainID,
+		Tx:      tx,
+		Raw:     payload,
+	}, nil
+}
+
+func (t *EvmTx) Type() TxType {
+	switch t.Tx.Type() {
+	case gtypes.AccessListTxType:
+		return EvmTxTypeAccessList
+	case gtypes.DynamicFeeTxType:
+		return EvmTxTypeDynamicFee
+	default:
+		return EvmTxTypeLegacy
+	}
+}
+
+// Signer returns the signer matching the transaction type.
+func (t *EvmTx) Signer() gtypes.Signer {
+	switch t.Tx.Type() {
+	case gtypes.AccessListTxType:
+		return gtypes.NewEIP2930Signer(t.ChainID)
+	case gtypes.DynamicFeeTxType:
+		return gtypes.NewLondonSigner(t.ChainID)
+	default:
+		return gtypes.NewEIP155Signer(t.ChainID)
+	}
+}
+
+// SigningHash returns the hash the vault has to sign for this transaction.
+func (t *EvmTx) SigningHash() ecommon.Hash {
+	return t.Signer().Hash(t.Tx)
+}
+
+func (t *EvmTx) SigningHashes() ([][]byte, error) {
+	return [][]byte{t.SigningHash().Bytes()}, nil
+}
+
+func (t *EvmTx) Digest() string {
+	return t.SigningHash().Hex()
+}
+
+// PolicyPayload returns the unsigned payload as submitted, which is what the EVM engine decodes.
+func (t *EvmTx) PolicyPayload() []byte {
+	return t.Raw
+}
+
+// Assemble combines the TSS signature with the unsigned transaction and checks that the
+// recovered sender is the expected vault address.
+func (t *EvmTx) Assemble(sig tss.KeysignResponse, expectedSender string) (*gtypes.Transaction, error) {
+	r, err := decodeSignatureComponent(sig.R, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature R: %w", err)
+	}
+	s, err := decodeSignatureComponent(sig.S, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature S: %w", err)
+	}
+	v, err := decodeSignatureComponent(sig.RecoveryID, 1)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature recovery ID: %w", err)
+	}
+	if v[0] >= 27 {
+		v[0] -= 27
+	}
+
+	rawSig := make([]byte, 0, 65)
+	rawSig = append(rawSig, r...)
+	rawSig = append(rawSig, s...)
+	rawSig = append(rawSig, v...)
+
+	signer := t.Signer()
+	signedTx, err := t.Tx.WithSignature(signer, rawSig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply signature: %w", err)
+	}
+
+	sender, err := gtypes.Sender(signer, signedTx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recover sender: %w", err)
+	}
+	if !ecommon.IsHexAddress(expectedSender) || sender != ecommon.HexToAddress(expectedSender) {
+		return nil, fmt.Errorf(
+			"recovered sender %s does not match vault address %s",
+			sender.Hex(),
+			expectedSender,
+		)
+	}
+
+	return signedTx, nil
+}
+
+// decodeSignatureComponent decodes a hex encoded signature value and left pads it to size bytes.
+func decodeSignatureComponent(value string, size int) ([]byte, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(value, "0x"))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > size {
+		return nil, fmt.Errorf("expected at most %d bytes, got %d", size, len(b))
+	}
+	return ecommon.LeftPadBytes(b, size), nil
+}
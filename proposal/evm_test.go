@@ -0,0 +1,126 @@
+package proposal
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	gtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/vultisig/mobile-tss-lib/tss"
+	vgcommon "github.com/vultisig/vultisig-go/common"
+)
+
+// The fixtures are signed on Ethereum mainnet by the key
+// 4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318.
+const evmFixtureSender = "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"
+
+var evmFixtures = []struct {
+	name     string
+	txType   TxType
+	unsigned string
+	signed   string
+	hash     string
+}{
+	{
+		name:     "legacy",
+		txType:   EvmTxTypeLegacy,
+		unsigned: "00e9098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a764000080",
+		signed:   "f86c098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a76400008025a0499aa1110848b179aa0f228e20faa3ba68b350e1feeab49638c6b8ce40ea56aea0053ec9b43dcea26f8d10b43a44bdfafae5b1b26462367921079005d4d274e06d",
+		hash:     "0xdaf5a779ae972f972197303d7b574746c7ef83eadac0f2791ad23db92e4c8e53",
+	},
+	{
+		name:     "eip-2930",
+		txType:   EvmTxTypeAccessList,
+		unsigned: "01f8a1010a8506fc23ac0082ea6094353535353535353535353535353535353535353580b844a9059cbb000000000000000000000000353535353535353535353535353535353535353500000000000000000000000000000000000000000000000000000000000f4240f838f7943535353535353535353535353535353535353535e1a00000000000000000000000000000000000000000000000000000000000000001",
+		signed:   "01f8e4010a8506fc23ac0082ea6094353535353535353535353535353535353535353580b844a9059cbb000000000000000000000000353535353535353535353535353535353535353500000000000000000000000000000000000000000000000000000000000f4240f838f7943535353535353535353535353535353535353535e1a0000000000000000000000000000000000000000000000000000000000000000101a01c437add5334c29ce80f7601cf5804a2d91055c43a568d1a2d0fba765da26663a034ea2d580ee9467ceee1ff7906557494930190ecfc5192af98b893fca448a484",
+		hash:     "0xbee1392eef5b1b1a4cb92ea9706325dad2d08ef8a6fd9721ef14d226ad048631",
+	},
+	{
+		name:     "eip-1559",
+		txType:   EvmTxTypeDynamicFee,
+		unsigned: "02f8a6010b84773594008509502f900082fde894353535353535353535353535353535353535353580b844a9059cbb000000000000000000000000353535353535353535353535353535353535353500000000000000000000000000000000000000000000000000000000000f4240f838f7943535353535353535353535353535353535353535e1a00000000000000000000000000000000000000000000000000000000000000001",
+		signed:   "02f8e9010b84773594008509502f900082fde894353535353535353535353535353535353535353580b844a9059cbb000000000000000000000000353535353535353535353535353535353535353500000000000000000000000000000000000000000000000000000000000f4240f838f7943535353535353535353535353535353535353535e1a0000000000000000000000000000000000000000000000000000000000000000180a051cdbc295621fe842182bfddb41d51ffa4c0b025edb14a6bf3b1db0f01e3dbaea022ff48064de3593a9c9d41412df1911029399f470d539d7c5db2b617a6c13572",
+		hash:     "0x24576e24af3d4a63fcc7d62503b09d31718e3f882370459514e4416925fadbcb",
+	},
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("invalid hex fixture: %v", err)
+	}
+	return b
+}
+
+func TestDecodeEvmTx(t *testing.T) {
+	for _, tc := range evmFixtures {
+		t.Run(tc.name, func(t *testing.T) {
+			var signedTx gtypes.Transaction
+			if err := signedTx.UnmarshalBinary(mustDecodeHex(t, tc.signed)); err != nil {
+				t.Fatalf("failed to decode signed fixture: %v", err)
+			}
+			// go-ethereum picks the signer of a signed transaction from its type and chain ID
+			gethSigner := gtypes.LatestSignerForChainID(big.NewInt(1))
+
+			evmTx, err := DecodeEvmTx(vgcommon.Ethereum, mustDecodeHex(t, tc.unsigned))
+			if err != nil {
+				t.Fatalf("DecodeEvmTx() error = %v", err)
+			}
+			if evmTx.Type() != tc.txType {
+				t.Errorf("Type() = %s, want %s", evmTx.Type(), tc.txType)
+			}
+			if got, want := evmTx.SigningHash(), gethSigner.Hash(&signedTx); got != want {
+				t.Errorf("SigningHash() = %s, go-ethereum signer hash = %s", got.Hex(), want.Hex())
+			}
+			if got := evmTx.Digest(); got != tc.hash {
+				t.Errorf("Digest() = %s, want %s", got, tc.hash)
+			}
+
+			v, r, s := signedTx.RawSignatureValues()
+			assembled, err := evmTx.Assemble(tss.KeysignResponse{
+				R:          hex.EncodeToString(r.Bytes()),
+				S:          hex.EncodeToString(s.Bytes()),
+				RecoveryID: hex.EncodeToString(recoveryID(signedTx.Type(), v)),
+			}, evmFixtureSender)
+			if err != nil {
+				t.Fatalf("Assemble() error = %v", err)
+			}
+			if assembled.Hash() != signedTx.Hash() {
+				t.Errorf("assembled tx hash = %s, want %s", assembled.Hash().Hex(), signedTx.Hash().Hex())
+			}
+
+			if _, err := evmTx.Assemble(tss.KeysignResponse{
+				R:          hex.EncodeToString(r.Bytes()),
+				S:          hex.EncodeToString(s.Bytes()),
+				RecoveryID: hex.EncodeToString(recoveryID(signedTx.Type(), v)),
+			}, ecommon.HexToAddress("0x01").Hex()); err == nil {
+				t.Error("Assemble() accepted a signature of another sender")
+			}
+		})
+	}
+}
+
+func TestDecodeEvmTxChainMismatch(t *testing.T) {
+	for _, tc := range evmFixtures {
+		if tc.txType == EvmTxTypeLegacy {
+			// Unsigned legacy transactions carry no chain ID
+			continue
+		}
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := DecodeEvmTx(vgcommon.Polygon, mustDecodeHex(t, tc.unsigned)); err == nil {
+				t.Error("DecodeEvmTx() accepted a mainnet transaction on polygon")
+			}
+		})
+	}
+}
+
+// recoveryID returns the y parity of the V value of a signed transaction.
+func recoveryID(txType uint8, v *big.Int) []byte {
+	if txType == gtypes.LegacyTxType {
+		// EIP-155: v = chain_id * 2 + 35 + y_parity
+		return []byte{byte(new(big.Int).Sub(v, big.NewInt(37)).Uint64())}
+	}
+	return []byte{byte(v.Uint64())}
+}
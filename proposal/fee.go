@@ -0,0 +1,133 @@
+package proposal
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Fee is what a transaction commits to pay the network, in the native base unit. GasLimit is set
+// for EVM and Cosmos transactions, FeePerGas only for EVM ones. Total is the most the transaction
+// can cost.
+type Fee struct {
+	GasLimit  uint64
+	FeePerGas *big.Int
+	Total     *big.Int
+}
+
+// TransactionFee returns the fee of the transaction, or false for transactions that don't commit
+// to a fee before they are signed and Cosmos transactions paying fees in another denom than the
+// native one.
+func TransactionFee(tx Tx) (*Fee, bool) {
+	switch t := tx.(type) {
+	case *EvmTx:
+		// GasFeeCap is the gas price of legacy and access list transactions
+		feePerGas := new(big.Int).Set(t.Tx.GasFeeCap())
+		return &Fee{
+			GasLimit:  t.Tx.Gas(),
+			FeePerGas: feePerGas,
+			Total:     new(big.Int).Mul(feePerGas, new(big.Int).SetUint64(t.Tx.Gas())),
+		}, true
+	case *UtxoTx:
+		return &Fee{Total: t.Fee()}, true
+	case *CosmosTx:
+		total, ok := t.NativeFee()
+		if !ok {
+			return nil, false
+		}
+		return &Fee{
+			GasLimit: t.GasLimit,
+			Total:    total,
+		}, true
+	default:
+		return nil, false
+	}
+}
+
+// ErrFeeNotChecked is returned when a fee ceiling applies to a transaction whose fee can't be
+// determined before signing.
+var ErrFeeNotChecked = errors.New("transaction fee can't be checked against the fee ceiling")
+
+// FeeCeiling caps the fee of a transaction. Zero or nil fields are not capped.
+type FeeCeiling struct {
+	MaxGasLimit  uint64
+	MaxFeePerGas *big.Int
+	MaxTotalFee  *big.Int
+}
+
+// IsZero reports whether the ceiling caps nothing.
+func (c FeeCeiling) IsZero() bool {
+	return c.MaxGasLimit == 0 && c.MaxFeePerGas == nil && c.MaxTotalFee == nil
+}
+
+// Narrow returns the lower of both ceilings for every field.
+func (c FeeCeiling) Narrow(other FeeCeiling) FeeCeiling {
+	narrowed := c
+	if other.MaxGasLimit > 0 && (narrowed.MaxGasLimit == 0 || other.MaxGasLimit < narrowed.MaxGasLimit) {
+		narrowed.MaxGasLimit = other.MaxGasLimit
+	}
+	narrowed.MaxFeePerGas = minCeiling(narrowed.MaxFeePerGas, other.MaxFeePerGas)
+	narrowed.MaxTotalFee = minCeiling(narrowed.MaxTotalFee, other.MaxTotalFee)
+	return narrowed
+}
+
+func minCeiling(a, b *big.Int) *big.Int {
+	if a == nil {
+		return b
+	}
+	if b == nil || a.Cmp(b) <= 0 {
+		return a
+	}
+	return b
+}
+
+// FeeViolation is a fee field above its ceiling.
+type FeeViolation struct {
+	Field   string `json:"field"`
+	Value   string `json:"value"`
+	Ceiling string `json:"ceiling"`
+}
+
+// FeeCeilingError is returned when the fee of a transaction exceeds its ceiling.
+type FeeCeilingError struct {
+	Violations []FeeViolation
+}
+
+func (e *FeeCeilingError) Error() string {
+	msgs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		msgs = append(msgs, fmt.Sprintf("%s %s above ceiling %s", v.Field, v.Value, v.Ceiling))
+	}
+	return "fee ceiling exceeded: " + strings.Join(msgs, ", ")
+}
+
+// Check returns a FeeCeilingError listing every field of the fee above the ceiling.
+func (c FeeCeiling) Check(fee Fee) error {
+	var violations []FeeViolation
+	if c.MaxGasLimit > 0 && fee.GasLimit > c.MaxGasLimit {
+		violations = append(violations, FeeViolation{
+			Field:   "gas_limit",
+			Value:   fmt.Sprintf("%d", fee.GasLimit),
+			Ceiling: fmt.Sprintf("%d", c.MaxGasLimit),
+		})
+	}
+	if c.MaxFeePerGas != nil && fee.FeePerGas != nil && fee.FeePerGas.Cmp(c.MaxFeePerGas) > 0 {
+		violations = append(violations, FeeViolation{
+			Field:   "fee_per_gas",
+			Value:   fee.FeePerGas.String(),
+			Ceiling: c.MaxFeePerGas.String(),
+		})
+	}
+	if c.MaxTotalFee != nil && fee.Total != nil && fee.Total.Cmp(c.MaxTotalFee) > 0 {
+		violations = append(violations, FeeViolation{
+			Field:   "total_fee",
+			Value:   fee.Total.String(),
+			Ceiling: c.MaxTotalFee.String(),
+		})
+	}
+	if len(violations) > 0 {
+		return &FeeCeilingError{Violations: violations}
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package proposal
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/google/uuid"
+	vgcommon "github.com/vultisig/vultisig-go/common"
+
+	"github.com/vultisig/pluginagent/storage/interfaces"
+	"github.com/vultisig/pluginagent/types"
+)
+
+const defaultNonceReservationTTL = 2 * time.Minute
+
+// NonceConflictError is returned when a proposal uses a nonce of its address that is held by
+// another proposal or by a reservation of another policy. ProposalID is nil for a reservation.
+type NonceConflictError struct {
+	Nonce      uint64
+	ProposalID *uuid.UUID
+}
+
+func (e *NonceConflictError) Error() string {
+	if e.ProposalID == nil {
+		return fmt.Sprintf("nonce %d is reserved by another policy", e.Nonce)
+	}
+	return fmt.Sprintf("nonce %d is already used by proposal %s", e.Nonce, *e.ProposalID)
+}
+
+// NonceManager hands out EVM nonces per vault address, so plugin workers proposing for the same
+// vault concurrently don't build transactions with the same nonce. Nonces are held in the nonce
+// ledger by reservations until they expire and by proposals until they fail, or for the
+// reservation TTL once they are signed, in case the signed transaction is never broadcast. The
+// account nonce of the chain marks the nonces below it as confirmed.
+type NonceManager struct {
+	db      interfaces.DatabaseStorage
+	clients map[vgcommon.Chain]*rpc.Client
+	ttl     time.Duration
+}
+
+func NewNonceManager(db interfaces.DatabaseStorage, endpoints map[string]string, ttl time.Duration) (*NonceManager, error) {
+	clients, err := dialEvmClients(endpoints)
+	if err != nil {
+		return nil, err
+	}
+	if ttl <= 0 {
+		ttl = defaultNonceReservationTTL
+	}
+	return &NonceManager{
+		db:      db,
+		clients: clients,
+		ttl:     ttl,
+	}, nil
+}
+
+// Reserve holds the lowest nonce of the address that is neither confirmed nor held for the policy.
+// Nonces of expired reservations, failed proposals and proposals signed more than the reservation
+// TTL ago are handed out again.
+func (m *NonceManager) Reserve(
+	ctx context.Context,
+	policyID uuid.UUID,
+	chain vgcommon.Chain,
+	address string,
+) (*types.NonceEntry, error) {
+	address = normalizeAddress(address)
+	accountNonce, err := m.accountNonce(ctx, chain, address)
+	if err != nil {
+		return nil, err
+	}
+
+	var reservation *types.NonceEntry
+	err = m.db.WithTx(ctx, func(db interfaces.DatabaseStorage) error {
+		if er := db.LockNonces(ctx, chain, address); er != nil {
+			return er
+		}
+		if er := db.DeleteNoncesBelow(ctx, chain, address, accountNonce); er != nil {
+			return er
+		}
+
+		now := time.Now().UTC()
+		held, er := db.ListHeldNonces(ctx, chain, address, accountNonce, now, m.ttl)
+		if er != nil {
+			return er
+		}
+		nonce := accountNonce
+		for _, entry := range held {
+			if entry.Nonce != nonce {
+				break
+			}
+			nonce++
+		}
+
+		id := uuid.New()
+		expiresAt := now.Add(m.ttl)
+		entry := types.NonceEntry{
+			Chain:         chain,
+			Address:       address,
+			Nonce:         nonce,
+			PolicyID:      policyID,
+			ReservationID: &id,
+			ExpiresAt:     &expiresAt,
+			CreatedAt:     now,
+		}
+		if er := db.UpsertNonceEntry(ctx, entry); er != nil {
+			return er
+		}
+		reservation = &entry
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reservation, nil
+}
+
+// Release frees a reservation of the policy that no proposal has claimed.
+func (m *NonceManager) Release(ctx context.Context, id uuid.UUID, policyID uuid.UUID) error {
+	return m.db.DeleteNonceReservation(ctx, id, policyID)
+}
+
+// Claim holds the nonces for the proposal. A nonce held by another proposal or by a reservation of
+// another policy is rejected with a NonceConflictError, a nonce held by a reservation of the
+// policy is taken over.
+func (m *NonceManager) Claim(
+	ctx context.Context,
+	policyID uuid.UUID,
+	proposalID uuid.UUID,
+	chain vgcommon.Chain,
+	address string,
+	nonces []uint64,
+) error {
+	if len(nonces) == 0 {
+		return nil
+	}
+	address = normalizeAddress(address)
+	fromNonce := nonces[0]
+	for _, nonce := range nonces[1:] {
+		fromNonce = min(fromNonce, nonce)
+	}
+
+	return m.db.WithTx(ctx, func(db interfaces.DatabaseStorage) error {
+		if er := db.LockNonces(ctx, chain, address); er != nil {
+			return er
+		}
+
+		held, er := db.ListHeldNonces(ctx, chain, address, fromNonce, time.Now().UTC(), m.ttl)
+		if er != nil {
+			return er
+		}
+		holders := make(map[uint64]types.NonceEntry, len(held))
+		for _, entry := range held {
+			holders[entry.Nonce] = entry
+		}
+
+		for _, nonce := range nonces {
+			if holder, ok := holders[nonce]; ok {
+				if holder.ProposalID != nil && *holder.ProposalID != proposalID {
+					return &NonceConflictError{
+						Nonce:      nonce,
+						ProposalID: holder.ProposalID,
+					}
+				}
+				if holder.ProposalID == nil && holder.PolicyID != policyID {
+					return &NonceConflictError{Nonce: nonce}
+				}
+			}
+			entry := types.NonceEntry{
+				Chain:      chain,
+				Address:    address,
+				Nonce:      nonce,
+				PolicyID:   policyID,
+				ProposalID: &proposalID,
+			}
+			if holder, ok := holders[nonce]; ok {
+				entry.ReservationID = holder.ReservationID
+			}
+			if er := db.UpsertNonceEntry(ctx, entry); er != nil {
+				return er
+			}
+		}
+		return nil
+	})
+}
+
+// accountNonce returns the next nonce the chain accepts for the address, counting transactions
+// in the mempool.
+func (m *NonceManager) accountNonce(ctx context.Context, chain vgcommon.Chain, address string) (uint64, error) {
+	client, ok := m.clients[chain]
+	if !ok {
+		return 0, fmt.Errorf("%w for chain %s", ErrNoRpcEndpoint, chain.String())
+	}
+
+	var nonce hexutil.Uint64
+	if err := client.CallContext(ctx, &nonce, "eth_getTransactionCount", ecommon.HexToAddress(address), "pending"); err != nil {
+		return 0, fmt.Errorf("failed to get account nonce: %w", err)
+	}
+	return uint64(nonce), nil
+}
+
+// normalizeAddress checksums the address, so ledger entries of an address match however it was
+// spelled.
+func normalizeAddress(address string) string {
+	return ecommon.HexToAddress(address).Hex()
+}
@@ -0,0 +1,30 @@
+package proposal
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	vgcommon "github.com/vultisig/vultisig-go/common"
+)
+
+// dialEvmClients creates a JSON-RPC client for every configured EVM chain.
+// Endpoints are keyed by chain name, e.g. "ethereum" or "Arbitrum".
+func dialEvmClients(endpoints map[string]string) (map[vgcommon.Chain]*rpc.Client, error) {
+	clients := make(map[vgcommon.Chain]*rpc.Client, len(endpoints))
+	for name, endpoint := range endpoints {
+		chain, err := vgcommon.FromString(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chain in rpc config: %w", err)
+		}
+		if !chain.IsEvm() {
+			return nil, fmt.Errorf("chain %s is not an EVM chain", chain.String())
+		}
+
+		client, err := rpc.DialHTTP(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to dial rpc for chain %s: %w", chain.String(), err)
+		}
+		clients[chain] = client
+	}
+	return clients, nil
+}
@@ -0,0 +1,512 @@
+package proposal
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/sirupsen/logrus"
+	v1 "github.com/vultisig/commondata/go/vultisig/vault/v1"
+	"github.com/vultisig/mobile-tss-lib/tss"
+	rtypes "github.com/vultisig/recipes/types"
+	"github.com/vultisig/verifier/plugin/keysign"
+	vtypes "github.com/vultisig/verifier/types"
+	"github.com/vultisig/verifier/vault"
+	"github.com/vultisig/vultisig-go/address"
+	vgcommon "github.com/vultisig/vultisig-go/common"
+
+	"github.com/vultisig/pluginagent/callback"
+	"github.com/vultisig/pluginagent/storage/interfaces"
+	"github.com/vultisig/pluginagent/types"
+)
+
+const (
+	QUEUE_NAME       = "proposal_queue"
+	TypeProposalSign = "proposal:sign"
+
+	// SignTimeout bounds a sign task, its keysign session included.
+	SignTimeout = 10 * time.Minute
+	// Proposals still signing this long after the sign task timed out were abandoned, e.g. by a
+	// crashed worker.
+	staleSigningGrace    = 2 * time.Minute
+	staleSigningInterval = time.Minute
+	errSigningAbandoned  = "signing did not complete within the sign timeout"
+)
+
+type SignTaskPayload struct {
+	ProposalID uuid.UUID `json:"proposal_id"`
+}
+
+// PolicyValidator checks a transaction against a policy and returns the rule that allows it.
+type PolicyValidator interface {
+	ValidateTransaction(policy vtypes.PluginPolicy, chain vgcommon.Chain, tx []byte) (*rtypes.Rule, error)
+}
+
+// Service signs queued proposals in the background and records the outcome.
+type Service struct {
+	db               interfaces.DatabaseStorage
+	signer           *keysign.Signer
+	broadcaster      Broadcaster
+	validator        PolicyValidator
+	vaultStorage     vault.Storage
+	encryptionSecret string
+	notifier         *callback.Notifier
+	logger           *logrus.Logger
+}
+
+func NewService(
+	db interfaces.DatabaseStorage,
+	signer *keysign.Signer,
+	broadcaster Broadcaster,
+	validator PolicyValidator,
+	vaultStorage vault.Storage,
+	encryptionSecret string,
+	notifier *callback.Notifier,
+	logger *logrus.Logger,
+) *Service {
+	return &Service{
+		db:               db,
+		signer:           signer,
+		broadcaster:      broadcaster,
+		validator:        validator,
+		vaultStorage:     vaultStorage,
+		encryptionSecret: encryptionSecret,
+		notifier:         notifier,
+		logger:           logger.WithField("pkg", "proposal").Logger,
+	}
+}
+
+// HandleSignTask is the asynq handler for TypeProposalSign tasks.
+func (s *Service) HandleSignTask(ctx context.Context, task *asynq.Task) error {
+	var payload SignTaskPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal task payload: %v: %w", err, asynq.SkipRetry)
+	}
+
+	return s.Sign(ctx, payload.ProposalID)
+}
+
+// Sign runs the TSS keysign for a queued proposal. Failures of the signing itself are recorded
+// on the proposal, only storage failures are returned.
+func (s *Service) Sign(ctx context.Context, proposalID uuid.UUID) error {
+	proposal, err := s.db.GetProposal(ctx, proposalID)
+	if err != nil {
+		return fmt.Errorf("failed to get proposal: %w", err)
+	}
+
+	logger := s.logger.WithField("proposal_id", proposalID)
+	switch proposal.Status {
+	case types.ProposalStatusSigned, types.ProposalStatusFailed:
+		logger.WithField("status", proposal.Status).Info("proposal already processed, skipping")
+		return nil
+	case types.ProposalStatusPendingApproval:
+		logger.Info("proposal is pending approval, skipping")
+		return nil
+	}
+
+	if err := s.db.UpdateProposalStatus(ctx, proposalID, types.ProposalStatusSigning, nil); err != nil {
+		return fmt.Errorf("failed to mark proposal as signing: %w", err)
+	}
+
+	if err := s.sign(ctx, proposal); err != nil {
+		logger.WithError(err).Error("failed to sign proposal")
+		errMsg := err.Error()
+		if er := s.db.UpdateProposalStatus(ctx, proposalID, types.ProposalStatusFailed, &errMsg); er != nil {
+			return fmt.Errorf("failed to mark proposal as failed: %w", er)
+		}
+		s.notify(ctx, *proposal)
+		return nil
+	}
+
+	if err := s.db.UpdateProposalSigned(ctx, *proposal); err != nil {
+		return fmt.Errorf("failed to store signed proposal: %w", err)
+	}
+
+	logger.Info("proposal signed")
+	s.notify(ctx, *proposal)
+	return nil
+}
+
+// FailStaleProposals periodically fails proposals left in signing past the sign timeout. Sign
+// tasks aren't retried, so nothing else moves them out of signing once their worker is gone.
+func (s *Service) FailStaleProposals(ctx context.Context) {
+	ticker := time.NewTicker(staleSigningInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		stale, err := s.db.FailStaleSigningProposals(ctx, SignTimeout+staleSigningGrace, errSigningAbandoned)
+		if err != nil {
+			s.logger.WithError(err).Error("failed to fail stale signing proposals")
+			continue
+		}
+		for _, p := range stale {
+			s.logger.WithField("proposal_id", p.ID).Warn("proposal abandoned while signing, marked as failed")
+			s.notify(ctx, p)
+		}
+	}
+}
+
+// notify queues the completion callback of the proposal, to the URL given on the proposal or the
+// one configured for the plugin of its policy.
+func (s *Service) notify(ctx context.Context, proposal types.Proposal) {
+	if s.notifier == nil {
+		return
+	}
+
+	var pluginID string
+	if proposal.CallbackURL == nil {
+		policy, err := s.db.GetPluginPolicy(ctx, proposal.PolicyID)
+		if err != nil {
+			s.logger.WithError(err).WithField("proposal_id", proposal.ID).Error("failed to get plugin policy for callback")
+			return
+		}
+		pluginID = policy.PluginID.String()
+	}
+	s.notifier.NotifyProposal(ctx, proposal, pluginID)
+}
+
+// signResult is the outcome of a chain specific keysign.
+type signResult struct {
+	signedTx []byte
+	txHash   string
+}
+
+// signJob is a transaction prepared for keysign. assemble builds the signed transaction from the
+// signatures of hashes, in the same order.
+type signJob struct {
+	chain    vgcommon.Chain
+	hashes   [][]byte
+	payload  []byte
+	assemble func(sigs []tss.KeysignResponse) (*signResult, error)
+}
+
+// sign signs every transaction of the proposal in one keysign session. The transactions are
+// checked against the current policy first, it may have changed or been deactivated since the
+// proposal was queued. Batch transactions are broadcast in order, a failed broadcast skips the
+// transactions after it.
+func (s *Service) sign(ctx context.Context, proposal *types.Proposal) error {
+	policy, err := s.db.GetPluginPolicy(ctx, proposal.PolicyID)
+	if err != nil {
+		return fmt.Errorf("failed to get plugin policy: %w", err)
+	}
+
+	v, err := s.getVault(policy.PublicKey, policy.PluginID.String())
+	if err != nil {
+		return err
+	}
+
+	txHexes := proposal.TxHexes()
+	jobs := make([]*signJob, 0, len(txHexes))
+	for i, txHex := range txHexes {
+		tx, er := hex.DecodeString(txHex)
+		if er != nil {
+			return fmt.Errorf("failed to decode tx hex of transaction %d: %w", i, er)
+		}
+		decoded, er := DecodeTx(proposal.Chain, tx)
+		if er != nil {
+			return fmt.Errorf("failed to decode transaction %d: %w", i, er)
+		}
+		if _, er := s.validator.ValidateTransaction(*policy, proposal.Chain, decoded.PolicyPayload()); er != nil {
+			return fmt.Errorf("transaction %d is no longer allowed by the policy: %w", i, er)
+		}
+		job, er := s.prepare(v, decoded)
+		if er != nil {
+			return fmt.Errorf("failed to prepare transaction %d: %w", i, er)
+		}
+		jobs = append(jobs, job)
+	}
+
+	results, signatures, err := s.signJobs(ctx, *policy, jobs)
+	if err != nil {
+		return err
+	}
+	proposal.Signatures = signatures
+
+	if len(proposal.Batch) == 0 {
+		signedTxHex := hex.EncodeToString(results[0].signedTx)
+		proposal.SignedTxHex = &signedTxHex
+		proposal.TxHash = &results[0].txHash
+		if proposal.Broadcast {
+			proposal.BroadcastTxHash, proposal.BroadcastError = s.broadcast(ctx, proposal.Chain, results[0])
+		}
+		return nil
+	}
+
+	var broadcastFailed *int
+	for i, result := range results {
+		signedTxHex := hex.EncodeToString(result.signedTx)
+		txHash := result.txHash
+		proposal.Batch[i].SignedTxHex = &signedTxHex
+		proposal.Batch[i].TxHash = &txHash
+		if !proposal.Broadcast {
+			continue
+		}
+		if broadcastFailed != nil {
+			errMsg := fmt.Sprintf("not broadcast, transaction %d failed to broadcast", *broadcastFailed)
+			proposal.Batch[i].BroadcastError = &errMsg
+			continue
+		}
+		proposal.Batch[i].BroadcastTxHash, proposal.Batch[i].BroadcastError = s.broadcast(ctx, proposal.Chain, result)
+		if proposal.Batch[i].BroadcastError != nil {
+			broadcastFailed = &i
+		}
+	}
+
+	return nil
+}
+
+// broadcast submits a signed transaction, returning either the broadcast hash or the error.
+func (s *Service) broadcast(ctx context.Context, chain vgcommon.Chain, result *signResult) (*string, *string) {
+	broadcastHash, err := s.broadcaster.Broadcast(ctx, chain, result.signedTx)
+	if err != nil {
+		s.logger.WithError(err).WithField("tx_hash", result.txHash).Error("failed to broadcast transaction")
+		errMsg := err.Error()
+		return nil, &errMsg
+	}
+	return &broadcastHash, nil
+}
+
+func (s *Service) prepare(v *v1.Vault, tx Tx) (*signJob, error) {
+	switch t := tx.(type) {
+	case *EvmTx:
+		return s.prepareEvm(v, t)
+	case *UtxoTx:
+		return s.prepareUtxo(v, t)
+	case *SolanaTx:
+		return s.prepareSolana(v, t)
+	case *CosmosTx:
+		return s.prepareCosmos(v, t)
+	default:
+		return nil, fmt.Errorf("unsupported transaction type %s", tx.Type())
+	}
+}
+
+// signJobs runs one keysign session for the messages of all jobs and assembles every
+// transaction. The signatures are returned keyed by keysign message hash.
+func (s *Service) signJobs(
+	ctx context.Context,
+	policy vtypes.PluginPolicy,
+	jobs []*signJob,
+) ([]*signResult, map[string]tss.KeysignResponse, error) {
+	signRequest := newPluginKeysignRequest(policy, jobs)
+	signatures, err := s.signer.Sign(ctx, *signRequest)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	results := make([]*signResult, 0, len(jobs))
+	messages := signRequest.Messages
+	for i, job := range jobs {
+		sigs := make([]tss.KeysignResponse, 0, len(job.hashes))
+		for j, m := range messages[:len(job.hashes)] {
+			sig, ok := signatures[m.Hash]
+			if !ok {
+				return nil, nil, fmt.Errorf("signature %d of transaction %d is missing", j, i)
+			}
+			sigs = append(sigs, sig)
+		}
+		messages = messages[len(job.hashes):]
+
+		result, er := job.assemble(sigs)
+		if er != nil {
+			return nil, nil, fmt.Errorf("failed to assemble signed transaction %d: %w", i, er)
+		}
+		results = append(results, result)
+	}
+
+	return results, signatures, nil
+}
+
+func (s *Service) prepareEvm(v *v1.Vault, evmTx *EvmTx) (*signJob, error) {
+	senderAddress, _, _, err := address.GetAddress(v.PublicKeyEcdsa, v.HexChainCode, evmTx.Chain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive vault address: %w", err)
+	}
+	hashes, err := evmTx.SigningHashes()
+	if err != nil {
+		return nil, err
+	}
+
+	return &signJob{
+		chain:   evmTx.Chain,
+		hashes:  hashes,
+		payload: evmTx.Raw,
+		assemble: func(sigs []tss.KeysignResponse) (*signResult, error) {
+			signedTx, er := evmTx.Assemble(sigs[0], senderAddress)
+			if er != nil {
+				return nil, er
+			}
+			signedTxBytes, er := signedTx.MarshalBinary()
+			if er != nil {
+				return nil, fmt.Errorf("failed to encode signed transaction: %w", er)
+			}
+			return &signResult{
+				signedTx: signedTxBytes,
+				txHash:   signedTx.Hash().Hex(),
+			}, nil
+		},
+	}, nil
+}
+
+func (s *Service) prepareUtxo(v *v1.Vault, utxoTx *UtxoTx) (*signJob, error) {
+	pubKeyHex, err := tss.GetDerivedPubKey(v.PublicKeyEcdsa, v.HexChainCode, utxoTx.Chain.GetDerivePath(), false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive vault public key: %w", err)
+	}
+	pubKey, err := hex.DecodeString(pubKeyHex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode vault public key: %w", err)
+	}
+
+	// Reject foreign inputs before spending a TSS session on them
+	if err := utxoTx.CheckOwner(pubKey); err != nil {
+		return nil, err
+	}
+
+	hashes, err := utxoTx.SigningHashes()
+	if err != nil {
+		return nil, err
+	}
+
+	return &signJob{
+		chain:   utxoTx.Chain,
+		hashes:  hashes,
+		payload: utxoTx.PolicyPayload(),
+		assemble: func(sigs []tss.KeysignResponse) (*signResult, error) {
+			signedTx, er := utxoTx.Assemble(sigs, pubKey)
+			if er != nil {
+				return nil, er
+			}
+			var buf bytes.Buffer
+			if er := signedTx.Serialize(&buf); er != nil {
+				return nil, fmt.Errorf("failed to encode signed transaction: %w", er)
+			}
+			return &signResult{
+				signedTx: buf.Bytes(),
+				txHash:   signedTx.TxHash().String(),
+			}, nil
+		},
+	}, nil
+}
+
+func (s *Service) prepareSolana(v *v1.Vault, solanaTx *SolanaTx) (*signJob, error) {
+	pubKey, err := hex.DecodeString(v.PublicKeyEddsa)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode vault EdDSA public key: %w", err)
+	}
+	if err := solanaTx.CheckSigner(pubKey); err != nil {
+		return nil, err
+	}
+
+	// The message chain is Solana, so the keysign runs with the vault EdDSA share
+	return &signJob{
+		chain:   solanaTx.Chain,
+		hashes:  [][]byte{solanaTx.Raw},
+		payload: solanaTx.Raw,
+		assemble: func(sigs []tss.KeysignResponse) (*signResult, error) {
+			signedTx, txSignature, er := solanaTx.Assemble(sigs[0], pubKey)
+			if er != nil {
+				return nil, er
+			}
+			return &signResult{
+				signedTx: signedTx,
+				txHash:   txSignature,
+			}, nil
+		},
+	}, nil
+}
+
+func (s *Service) prepareCosmos(v *v1.Vault, cosmosTx *CosmosTx) (*signJob, error) {
+	pubKeyHex, err := tss.GetDerivedPubKey(v.PublicKeyEcdsa, v.HexChainCode, cosmosTx.Chain.GetDerivePath(), false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive vault public key: %w", err)
+	}
+	pubKey, err := hex.DecodeString(pubKeyHex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode vault public key: %w", err)
+	}
+	if err := cosmosTx.CheckSigner(pubKey); err != nil {
+		return nil, err
+	}
+
+	hashes, err := cosmosTx.SigningHashes()
+	if err != nil {
+		return nil, err
+	}
+
+	return &signJob{
+		chain:   cosmosTx.Chain,
+		hashes:  hashes,
+		payload: cosmosTx.SignBytes,
+		assemble: func(sigs []tss.KeysignResponse) (*signResult, error) {
+			txRaw, txHash, er := cosmosTx.Assemble(sigs[0], pubKey)
+			if er != nil {
+				return nil, er
+			}
+			return &signResult{
+				signedTx: txRaw,
+				txHash:   txHash,
+			}, nil
+		},
+	}, nil
+}
+
+// newPluginKeysignRequest builds a keysign request with one message per signing hash of every
+// job, the same way vtypes.NewPluginKeysignRequestEvm does for the single EVM hash. Messages of
+// a batch carry their transaction as raw message.
+func newPluginKeysignRequest(policy vtypes.PluginPolicy, jobs []*signJob) *vtypes.PluginKeysignRequest {
+	var messages []vtypes.KeysignMessage
+	for _, job := range jobs {
+		for _, hash := range job.hashes {
+			msg := vtypes.KeysignMessage{
+				Message:      base64.StdEncoding.EncodeToString(hash),
+				Chain:        job.chain,
+				Hash:         keysignMessageHash(hash),
+				HashFunction: vtypes.HashFunction_SHA256,
+			}
+			if len(jobs) > 1 {
+				msg.RawMessage = base64.StdEncoding.EncodeToString(job.payload)
+			}
+			messages = append(messages, msg)
+		}
+	}
+
+	return &vtypes.PluginKeysignRequest{
+		KeysignRequest: vtypes.KeysignRequest{
+			PublicKey: policy.PublicKey,
+			Messages:  messages,
+			PolicyID:  policy.ID,
+			PluginID:  policy.PluginID.String(),
+		},
+		Transaction: base64.StdEncoding.EncodeToString(jobs[0].payload),
+	}
+}
+
+func (s *Service) getVault(publicKeyECDSA, pluginID string) (*v1.Vault, error) {
+	if len(s.encryptionSecret) == 0 {
+		return nil, fmt.Errorf("no encryption secret")
+	}
+	fileName := vgcommon.GetVaultBackupFilename(publicKeyECDSA, pluginID)
+	vaultContent, err := s.vaultStorage.GetVault(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vault, err: %w", err)
+	}
+
+	v, err := vgcommon.DecryptVaultFromBackup(s.encryptionSecret, vaultContent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt vault,err: %w", err)
+	}
+	return v, nil
+}
@@ -0,0 +1,146 @@
+package proposal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	vgcommon "github.com/vultisig/vultisig-go/common"
+)
+
+var (
+	ErrNoRpcEndpoint  = errors.New("no rpc endpoint configured")
+	ErrGasLimitTooLow = errors.New("gas limit is below the estimate")
+)
+
+// RevertError is returned when a simulated transaction reverts. Reason is the decoded
+// Error(string) or Panic(uint256) message, Data the raw revert data.
+type RevertError struct {
+	Reason string
+	Data   []byte
+}
+
+func (e *RevertError) Error() string {
+	if e.Reason != "" {
+		return "transaction reverted: " + e.Reason
+	}
+	if len(e.Data) > 0 {
+		return "transaction reverted with data " + hexutil.Encode(e.Data)
+	}
+	return "transaction reverted"
+}
+
+// SimulationResult is the outcome of a transaction that does not revert.
+type SimulationResult struct {
+	GasEstimate uint64
+	ReturnData  []byte
+}
+
+// Simulator runs an unsigned EVM transaction against the latest chain state, so transactions
+// that would revert are rejected before a TSS session is spent on them.
+type Simulator interface {
+	Simulate(ctx context.Context, from ecommon.Address, tx *EvmTx) (*SimulationResult, error)
+}
+
+var _ Simulator = (*EvmSimulator)(nil)
+
+// EvmSimulator simulates through eth_call and eth_estimateGas on the configured chain RPC.
+type EvmSimulator struct {
+	clients map[vgcommon.Chain]*rpc.Client
+}
+
+func NewEvmSimulator(endpoints map[string]string) (*EvmSimulator, error) {
+	clients, err := dialEvmClients(endpoints)
+	if err != nil {
+		return nil, err
+	}
+	return &EvmSimulator{
+		clients: clients,
+	}, nil
+}
+
+// simulationCall is the eth_call transaction object. Fee fields are left out, the sender may
+// not hold enough balance for the fee at simulation time.
+type simulationCall struct {
+	From       ecommon.Address   `json:"from"`
+	To         *ecommon.Address  `json:"to,omitempty"`
+	Gas        *hexutil.Uint64   `json:"gas,omitempty"`
+	Value      *hexutil.Big      `json:"value,omitempty"`
+	Data       hexutil.Bytes     `json:"data,omitempty"`
+	AccessList gtypes.AccessList `json:"accessList,omitempty"`
+}
+
+// Simulate calls the transaction as the vault and estimates its gas. A revert of either call
+// is returned as a RevertError, a gas limit below the estimate as ErrGasLimitTooLow.
+func (s *EvmSimulator) Simulate(ctx context.Context, from ecommon.Address, tx *EvmTx) (*SimulationResult, error) {
+	client, ok := s.clients[tx.Chain]
+	if !ok {
+		return nil, fmt.Errorf("%w for chain %s", ErrNoRpcEndpoint, tx.Chain.String())
+	}
+
+	call := simulationCall{
+		From:       from,
+		To:         tx.Tx.To(),
+		Value:      (*hexutil.Big)(new(big.Int).Set(tx.Tx.Value())),
+		Data:       tx.Tx.Data(),
+		AccessList: tx.Tx.AccessList(),
+	}
+
+	// Estimate without a gas limit so the estimate isn't capped by the proposed one
+	var gasEstimate hexutil.Uint64
+	if err := client.CallContext(ctx, &gasEstimate, "eth_estimateGas", call); err != nil {
+		if revert := revertFromError(err); revert != nil {
+			return nil, revert
+		}
+		return nil, fmt.Errorf("failed to estimate gas: %w", err)
+	}
+	if tx.Tx.Gas() < uint64(gasEstimate) {
+		return nil, fmt.Errorf("%w: limit %d, estimate %d", ErrGasLimitTooLow, tx.Tx.Gas(), uint64(gasEstimate))
+	}
+
+	gas := hexutil.Uint64(tx.Tx.Gas())
+	call.Gas = &gas
+	var returnData hexutil.Bytes
+	if err := client.CallContext(ctx, &returnData, "eth_call", call, "latest"); err != nil {
+		if revert := revertFromError(err); revert != nil {
+			return nil, revert
+		}
+		return nil, fmt.Errorf("failed to call transaction: %w", err)
+	}
+
+	return &SimulationResult{
+		GasEstimate: uint64(gasEstimate),
+		ReturnData:  returnData,
+	}, nil
+}
+
+// revertFromError extracts the revert of a JSON-RPC error. Nodes report reverts with error code 3
+// and the revert data as error data, older nodes only with an "execution reverted" message.
+func revertFromError(err error) *RevertError {
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if data, ok := dataErr.ErrorData().(string); ok {
+			revertData, er := hexutil.Decode(data)
+			if er == nil {
+				revert := &RevertError{Data: revertData}
+				if reason, e := abi.UnpackRevert(revertData); e == nil {
+					revert.Reason = reason
+				}
+				return revert
+			}
+		}
+	}
+
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && (rpcErr.ErrorCode() == 3 || strings.HasPrefix(rpcErr.Error(), "execution reverted")) {
+		return &RevertError{Reason: rpcErr.Error()}
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package proposal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	vgcommon "github.com/vultisig/vultisig-go/common"
+)
+
+// rpcReply is the answer of the simulation server to a method, either a result or an error.
+type rpcReply struct {
+	result any
+	err    map[string]any
+}
+
+// newSimulationServer answers every method with its reply and records the methods called.
+func newSimulationServer(t *testing.T, replies map[string]rpcReply) (*httptest.Server, *[]string) {
+	t.Helper()
+	var called []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode rpc request: %v", err)
+			return
+		}
+		called = append(called, req.Method)
+
+		resp := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+		reply, ok := replies[req.Method]
+		switch {
+		case !ok:
+			resp["error"] = map[string]any{"code": -32601, "message": "method not found"}
+		case reply.err != nil:
+			resp["error"] = reply.err
+		default:
+			resp["result"] = reply.result
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &called
+}
+
+// errorRevertData is the revert data of a require with a reason, the ABI encoded Error(string).
+func errorRevertData(t *testing.T, reason string) []byte {
+	t.Helper()
+	stringType, err := abi.NewType("string", "", nil)
+	if err != nil {
+		t.Fatalf("failed to create abi type: %v", err)
+	}
+	packed, err := abi.Arguments{{Type: stringType}}.Pack(reason)
+	if err != nil {
+		t.Fatalf("failed to pack revert reason: %v", err)
+	}
+	return append([]byte{0x08, 0xc3, 0x79, 0xa0}, packed...)
+}
+
+func TestEvmSimulator(t *testing.T) {
+	// The unsigned EIP-1559 fixture of TestDecodeEvmTx, with a gas limit of 65000
+	tx, err := DecodeEvmTx(vgcommon.Ethereum, mustDecodeHex(t, evmFixtures[2].unsigned))
+	if err != nil {
+		t.Fatalf("DecodeEvmTx() error = %v", err)
+	}
+	revertData := errorRevertData(t, "insufficient allowance")
+
+	tests := []struct {
+		name       string
+		replies    map[string]rpcReply
+		wantCalls  []string
+		wantRevert *RevertError
+		wantErr    error
+		wantGas    uint64
+		wantReturn []byte
+	}{
+		{
+			name: "success",
+			replies: map[string]rpcReply{
+				"eth_estimateGas": {result: "0xc350"},
+				"eth_call":        {result: "0x01"},
+			},
+			wantCalls:  []string{"eth_estimateGas", "eth_call"},
+			wantGas:    50000,
+			wantReturn: []byte{0x01},
+		},
+		{
+			name: "abi error revert",
+			replies: map[string]rpcReply{
+				"eth_estimateGas": {err: map[string]any{
+					"code":    3,
+					"message": "execution reverted: insufficient allowance",
+					"data":    hexutil.Encode(revertData),
+				}},
+			},
+			wantCalls:  []string{"eth_estimateGas"},
+			wantRevert: &RevertError{Reason: "insufficient allowance", Data: revertData},
+		},
+		{
+			name: "revert with message only",
+			replies: map[string]rpcReply{
+				"eth_estimateGas": {err: map[string]any{"code": 3, "message": "execution reverted"}},
+			},
+			wantCalls:  []string{"eth_estimateGas"},
+			wantRevert: &RevertError{Reason: "execution reverted"},
+		},
+		{
+			name: "revert on call",
+			replies: map[string]rpcReply{
+				"eth_estimateGas": {result: "0xc350"},
+				"eth_call": {err: map[string]any{
+					"code":    3,
+					"message": "execution reverted",
+					"data":    hexutil.Encode(revertData),
+				}},
+			},
+			wantCalls:  []string{"eth_estimateGas", "eth_call"},
+			wantRevert: &RevertError{Reason: "insufficient allowance", Data: revertData},
+		},
+		{
+			name: "gas limit below estimate",
+			replies: map[string]rpcReply{
+				"eth_estimateGas": {result: "0xfde9"},
+				"eth_call":        {result: "0x"},
+			},
+			wantCalls: []string{"eth_estimateGas"},
+			wantErr:   ErrGasLimitTooLow,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, called := newSimulationServer(t, tc.replies)
+			simulator, err := NewEvmSimulator(map[string]string{"ethereum": srv.URL})
+			if err != nil {
+				t.Fatalf("NewEvmSimulator() error = %v", err)
+			}
+
+			result, err := simulator.Simulate(context.Background(), ecommon.HexToAddress(evmFixtureSender), tx)
+			if len(*called) != len(tc.wantCalls) {
+				t.Fatalf("rpc calls = %v, want %v", *called, tc.wantCalls)
+			}
+			for i, method := range tc.wantCalls {
+				if (*called)[i] != method {
+					t.Errorf("rpc call %d = %s, want %s", i, (*called)[i], method)
+				}
+			}
+
+			switch {
+			case tc.wantRevert != nil:
+				var revert *RevertError
+				if !errors.As(err, &revert) {
+					t.Fatalf("Simulate() error = %v, want a RevertError", err)
+				}
+				if revert.Reason != tc.wantRevert.Reason {
+					t.Errorf("Reason = %q, want %q", revert.Reason, tc.wantRevert.Reason)
+				}
+				if hexutil.Encode(revert.Data) != hexutil.Encode(tc.wantRevert.Data) {
+					t.Errorf("Data = %x, want %x", revert.Data, tc.wantRevert.Data)
+				}
+			case tc.wantErr != nil:
+				if !errors.Is(err, tc.wantErr) {
+					t.Fatalf("Simulate() error = %v, want %v", err, tc.wantErr)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("Simulate() error = %v", err)
+				}
+				if result.GasEstimate != tc.wantGas {
+					t.Errorf("GasEstimate = %d, want %d", result.GasEstimate, tc.wantGas)
+				}
+				if hexutil.Encode(result.ReturnData) != hexutil.Encode(tc.wantReturn) {
+					t.Errorf("ReturnData = %x, want %x", result.ReturnData, tc.wantReturn)
+				}
+			}
+		})
+	}
+}
+
+func TestEvmSimulatorNodeError(t *testing.T) {
+	srv, _ := newSimulationServer(t, map[string]rpcReply{
+		"eth_estimateGas": {err: map[string]any{"code": -32000, "message": "header not found"}},
+	})
+	simulator, err := NewEvmSimulator(map[string]string{"ethereum": srv.URL})
+	if err != nil {
+		t.Fatalf("NewEvmSimulator() error = %v", err)
+	}
+	tx, err := DecodeEvmTx(vgcommon.Ethereum, mustDecodeHex(t, evmFixtures[2].unsigned))
+	if err != nil {
+		t.Fatalf("DecodeEvmTx() error = %v", err)
+	}
+
+	_, err = simulator.Simulate(context.Background(), ecommon.HexToAddress(evmFixtureSender), tx)
+	var revert *RevertError
+	if err == nil || errors.As(err, &revert) {
+		t.Errorf("Simulate() error = %v, want a node error that is not a revert", err)
+	}
+}
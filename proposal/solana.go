@@ -0,0 +1,266 @@
+package proposal
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/vultisig/mobile-tss-lib/tss"
+	vgcommon "github.com/vultisig/vultisig-go/common"
+)
+
+const (
+	TxTypeSolanaLegacy TxType = "solana_legacy"
+	TxTypeSolanaV0     TxType = "solana_v0"
+
+	solanaVersionPrefix = 0x80
+	solanaKeySize       = 32
+)
+
+type SolanaInstruction struct {
+	ProgramIDIndex uint8
+	Accounts       []uint8
+	Data           []byte
+}
+
+// SolanaMessage is a decoded legacy or v0 Solana transaction message. Address table lookups
+// are validated but not kept, program IDs are always static account keys.
+type SolanaMessage struct {
+	Versioned             bool
+	NumRequiredSignatures uint8
+	NumReadonlySigned     uint8
+	NumReadonlyUnsigned   uint8
+	AccountKeys           [][]byte
+	RecentBlockhash       []byte
+	Instructions          []SolanaInstruction
+}
+
+// ProgramID returns the base58 program ID of the instruction at index i.
+func (m *SolanaMessage) ProgramID(i int) (string, error) {
+	if i < 0 || i >= len(m.Instructions) {
+		return "", fmt.Errorf("instruction %d is out of range", i)
+	}
+	index := int(m.Instructions[i].ProgramIDIndex)
+	if index >= len(m.AccountKeys) {
+		return "", fmt.Errorf("instruction %d program ID index %d is out of range", i, index)
+	}
+	return base58.Encode(m.AccountKeys[index]), nil
+}
+
+// DecodeSolanaMessage parses a serialized legacy or v0 message.
+func DecodeSolanaMessage(payload []byte) (*SolanaMessage, error) {
+	r := bytes.NewReader(payload)
+	msg := &SolanaMessage{}
+
+	prefix, err := r.ReadByte()
+	if err != nil {
+		return nil, errors.New("empty message")
+	}
+	if prefix&solanaVersionPrefix != 0 {
+		if version := prefix &^ solanaVersionPrefix; version != 0 {
+			return nil, fmt.Errorf("unsupported message version %d", version)
+		}
+		msg.Versioned = true
+		if prefix, err = r.ReadByte(); err != nil {
+			return nil, errors.New("missing message header")
+		}
+	}
+	msg.NumRequiredSignatures = prefix
+	if msg.NumReadonlySigned, err = r.ReadByte(); err != nil {
+		return nil, errors.New("missing message header")
+	}
+	if msg.NumReadonlyUnsigned, err = r.ReadByte(); err != nil {
+		return nil, errors.New("missing message header")
+	}
+
+	numKeys, err := readShortVec(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read account keys length: %w", err)
+	}
+	for i := 0; i < numKeys; i++ {
+		key, er := readSolanaBytes(r, solanaKeySize)
+		if er != nil {
+			return nil, fmt.Errorf("failed to read account key %d: %w", i, er)
+		}
+		msg.AccountKeys = append(msg.AccountKeys, key)
+	}
+	if int(msg.NumRequiredSignatures) > len(msg.AccountKeys) {
+		return nil, errors.New("more required signatures than account keys")
+	}
+
+	if msg.RecentBlockhash, err = readSolanaBytes(r, solanaKeySize); err != nil {
+		return nil, fmt.Errorf("failed to read recent blockhash: %w", err)
+	}
+
+	numInstructions, err := readShortVec(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read instructions length: %w", err)
+	}
+	for i := 0; i < numInstructions; i++ {
+		var inst SolanaInstruction
+		if inst.ProgramIDIndex, err = r.ReadByte(); err != nil {
+			return nil, fmt.Errorf("failed to read instruction %d program ID index: %w", i, err)
+		}
+		if int(inst.ProgramIDIndex) >= len(msg.AccountKeys) {
+			return nil, fmt.Errorf("instruction %d program ID index is out of range", i)
+		}
+		if inst.Accounts, err = readSolanaVec(r); err != nil {
+			return nil, fmt.Errorf("failed to read instruction %d accounts: %w", i, err)
+		}
+		if inst.Data, err = readSolanaVec(r); err != nil {
+			return nil, fmt.Errorf("failed to read instruction %d data: %w", i, err)
+		}
+		msg.Instructions = append(msg.Instructions, inst)
+	}
+
+	if msg.Versioned {
+		numLookups, er := readShortVec(r)
+		if er != nil {
+			return nil, fmt.Errorf("failed to read address table lookups length: %w", er)
+		}
+		for i := 0; i < numLookups; i++ {
+			if _, er := readSolanaBytes(r, solanaKeySize); er != nil {
+				return nil, fmt.Errorf("failed to read address table lookup %d: %w", i, er)
+			}
+			if _, er := readSolanaVec(r); er != nil {
+				return nil, fmt.Errorf("failed to read address table lookup %d writable indexes: %w", i, er)
+			}
+			if _, er := readSolanaVec(r); er != nil {
+				return nil, fmt.Errorf("failed to read address table lookup %d readonly indexes: %w", i, er)
+			}
+		}
+	}
+
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("%d trailing bytes after message", r.Len())
+	}
+	return msg, nil
+}
+
+// readShortVec reads Solana's compact-u16 length encoding.
+func readShortVec(r *bytes.Reader) (int, error) {
+	var value int
+	for i := 0; i < 3; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, errors.New("unexpected end of message")
+		}
+		value |= int(b&0x7f) << (7 * i)
+		if b&0x80 == 0 {
+			return value, nil
+		}
+	}
+	return 0, errors.New("compact-u16 value is too long")
+}
+
+func readSolanaBytes(r *bytes.Reader, size int) ([]byte, error) {
+	if r.Len() < size {
+		return nil, errors.New("unexpected end of message")
+	}
+	b := make([]byte, size)
+	_, _ = r.Read(b)
+	return b, nil
+}
+
+func readSolanaVec(r *bytes.Reader) ([]byte, error) {
+	size, err := readShortVec(r)
+	if err != nil {
+		return nil, err
+	}
+	return readSolanaBytes(r, size)
+}
+
+// SolanaTx is a serialized Solana message signed with the vault EdDSA key. The vault must be
+// the fee payer and the only signer.
+type SolanaTx struct {
+	Chain   vgcommon.Chain
+	Message *SolanaMessage
+	Raw     []byte
+}
+
+func DecodeSolanaTx(chain vgcommon.Chain, payload []byte) (*SolanaTx, error) {
+	if chain != vgcommon.Solana {
+		return nil, fmt.Errorf("chain %s is not Solana", chain.String())
+	}
+
+	msg, err := DecodeSolanaMessage(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode solana message: %w", err)
+	}
+	if msg.NumRequiredSignatures != 1 {
+		return nil, fmt.Errorf("message requires %d signatures, only the vault may sign", msg.NumRequiredSignatures)
+	}
+
+	return &SolanaTx{
+		Chain:   chain,
+		Message: msg,
+		Raw:     payload,
+	}, nil
+}
+
+func (t *SolanaTx) Type() TxType {
+	if t.Message.Versioned {
+		return TxTypeSolanaV0
+	}
+	return TxTypeSolanaLegacy
+}
+
+func (t *SolanaTx) PolicyPayload() []byte {
+	return t.Raw
+}
+
+func (t *SolanaTx) Digest() string {
+	hash := sha256.Sum256(t.Raw)
+	return hex.EncodeToString(hash[:])
+}
+
+// SigningHashes returns the message itself, EdDSA signs the full message rather than a hash.
+func (t *SolanaTx) SigningHashes() ([][]byte, error) {
+	return [][]byte{t.Raw}, nil
+}
+
+// CheckSigner verifies that the fee payer is the given EdDSA public key.
+func (t *SolanaTx) CheckSigner(pubKey []byte) error {
+	if !bytes.Equal(t.Message.AccountKeys[0], pubKey) {
+		return fmt.Errorf(
+			"fee payer %s is not the vault address %s",
+			base58.Encode(t.Message.AccountKeys[0]),
+			base58.Encode(pubKey),
+		)
+	}
+	return nil
+}
+
+// Assemble prefixes the message with the verified vault signature. It returns the serialized
+// transaction and its signature, which is the Solana transaction ID.
+func (t *SolanaTx) Assemble(sig tss.KeysignResponse, pubKey []byte) ([]byte, string, error) {
+	if err := t.CheckSigner(pubKey); err != nil {
+		return nil, "", err
+	}
+
+	r, err := decodeSignatureComponent(sig.R, 32)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid signature R: %w", err)
+	}
+	s, err := decodeSignatureComponent(sig.S, 32)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid signature S: %w", err)
+	}
+	signature := make([]byte, 0, ed25519.SignatureSize)
+	signature = append(signature, r...)
+	signature = append(signature, s...)
+	if !ed25519.Verify(pubKey, t.Raw, signature) {
+		return nil, "", errors.New("signature does not verify against the vault EdDSA public key")
+	}
+
+	signedTx := make([]byte, 0, 1+len(signature)+len(t.Raw))
+	// compact-u16 signature count, always 1
+	signedTx = append(signedTx, 1)
+	signedTx = append(signedTx, signature...)
+	signedTx = append(signedTx, t.Raw...)
+	return signedTx, base58.Encode(signature), nil
+}
@@ -0,0 +1,44 @@
+package proposal
+
+import (
+	"fmt"
+
+	vgcommon "github.com/vultisig/vultisig-go/common"
+)
+
+type TxType string
+
+// Tx is an unsigned transaction proposed for one of the supported chain families.
+type Tx interface {
+	Type() TxType
+	// PolicyPayload returns the transaction in the encoding the recipe engine evaluates.
+	PolicyPayload() []byte
+	// Digest identifies the unsigned transaction and is used to deduplicate proposals.
+	Digest() string
+	// SigningHashes returns the hashes the vault has to sign, in keysign message order.
+	// EdDSA chains sign the message itself.
+	SigningHashes() ([][]byte, error)
+}
+
+var (
+	_ Tx = (*EvmTx)(nil)
+	_ Tx = (*UtxoTx)(nil)
+	_ Tx = (*SolanaTx)(nil)
+	_ Tx = (*CosmosTx)(nil)
+)
+
+// DecodeTx decodes a proposal payload according to the chain family.
+func DecodeTx(chain vgcommon.Chain, payload []byte) (Tx, error) {
+	switch {
+	case chain.IsEvm():
+		return DecodeEvmTx(chain, payload)
+	case IsUtxoChain(chain):
+		return DecodeUtxoTx(chain, payload)
+	case chain == vgcommon.Solana:
+		return DecodeSolanaTx(chain, payload)
+	case IsCosmosChain(chain):
+		return DecodeCosmosTx(chain, payload)
+	default:
+		return nil, fmt.Errorf("chain %s is not supported", chain.String())
+	}
+}
@@ -0,0 +1,351 @@
+package proposal
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/vultisig/mobile-tss-lib/tss"
+	vgcommon "github.com/vultisig/vultisig-go/common"
+)
+
+const TxTypePsbt TxType = "psbt"
+
+// utxoChains are the UTXO chains whose sighash algorithms match bitcoin's. Bitcoin Cash is
+// missing on purpose, it requires SIGHASH_FORKID.
+var utxoChains = map[vgcommon.Chain]bool{
+	vgcommon.Bitcoin:  true,
+	vgcommon.Litecoin: true,
+	vgcommon.Dogecoin: true,
+}
+
+func IsUtxoChain(chain vgcommon.Chain) bool {
+	return utxoChains[chain]
+}
+
+type utxoScriptType int
+
+const (
+	utxoScriptP2PKH utxoScriptType = iota
+	utxoScriptP2WPKH
+	utxoScriptP2SHP2WPKH
+)
+
+// utxoInput is a resolved PSBT input, i.e. the output it spends and how it is spent.
+type utxoInput struct {
+	scriptType utxoScriptType
+	prevOut    *wire.TxOut
+	pubKeyHash []byte
+	// subScript is the script committed to by the sighash
+	subScript    []byte
+	redeemScript []byte
+}
+
+// UtxoTx is a PSBT proposed on a UTXO chain. Every input has to be a P2PKH, P2WPKH or
+// P2SH-P2WPKH output of the vault and is signed with SIGHASH_ALL.
+type UtxoTx struct {
+	Chain     vgcommon.Chain
+	Packet    *psbt.Packet
+	inputs    []utxoInput
+	sigHashes *txscript.TxSigHashes
+}
+
+func DecodeUtxoTx(chain vgcommon.Chain, payload []byte) (*UtxoTx, error) {
+	if !IsUtxoChain(chain) {
+		return nil, fmt.Errorf("chain %s is not a supported UTXO chain", chain.String())
+	}
+
+	r := bytes.NewReader(payload)
+	packet, err := psbt.NewFromRawBytes(r, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode psbt: %w", err)
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("failed to decode psbt: %d trailing bytes", r.Len())
+	}
+
+	tx := packet.UnsignedTx
+	prevOuts := make(map[wire.OutPoint]*wire.TxOut, len(tx.TxIn))
+	inputs := make([]utxoInput, len(tx.TxIn))
+	for i, txIn := range tx.TxIn {
+		in := packet.Inputs[i]
+		if len(in.FinalScriptSig) != 0 || len(in.FinalScriptWitness) != 0 {
+			return nil, fmt.Errorf("input %d is already finalized", i)
+		}
+		// An input without a sighash type is signed with SIGHASH_ALL
+		if in.SighashType != 0 && in.SighashType != txscript.SigHashAll {
+			return nil, fmt.Errorf("input %d requests sighash type 0x%x, only SIGHASH_ALL is allowed", i, in.SighashType)
+		}
+
+		prevOut, er := resolvePrevOut(txIn.PreviousOutPoint, in)
+		if er != nil {
+			return nil, fmt.Errorf("input %d: %w", i, er)
+		}
+		prevOuts[txIn.PreviousOutPoint] = prevOut
+
+		input, er := resolveUtxoInput(chain, prevOut, in.RedeemScript)
+		if er != nil {
+			return nil, fmt.Errorf("input %d: %w", i, er)
+		}
+		// Legacy inputs are finalized from the previous transaction, BIP-174 has no witness
+		// utxo for them
+		if input.scriptType == utxoScriptP2PKH && (in.NonWitnessUtxo == nil || in.WitnessUtxo != nil) {
+			return nil, fmt.Errorf("input %d: P2PKH inputs need a non-witness utxo and no witness utxo", i)
+		}
+		inputs[i] = *input
+	}
+
+	return &UtxoTx{
+		Chain:     chain,
+		Packet:    packet,
+		inputs:    inputs,
+		sigHashes: txscript.NewTxSigHashes(tx, txscript.NewMultiPrevOutFetcher(prevOuts)),
+	}, nil
+}
+
+func resolvePrevOut(outPoint wire.OutPoint, in psbt.PInput) (*wire.TxOut, error) {
+	if in.NonWitnessUtxo != nil {
+		if in.NonWitnessUtxo.TxHash() != outPoint.Hash {
+			return nil, fmt.Errorf("non-witness utxo does not match previous outpoint %s", outPoint.String())
+		}
+		if int(outPoint.Index) >= len(in.NonWitnessUtxo.TxOut) {
+			return nil, fmt.Errorf("previous outpoint %s is out of range", outPoint.String())
+		}
+		prevOut := in.NonWitnessUtxo.TxOut[outPoint.Index]
+		if in.WitnessUtxo != nil && (in.WitnessUtxo.Value != prevOut.Value ||
+			!bytes.Equal(in.WitnessUtxo.PkScript, prevOut.PkScript)) {
+			return nil, fmt.Errorf("witness utxo does not match non-witness utxo")
+		}
+		return prevOut, nil
+	}
+	if in.WitnessUtxo != nil {
+		return in.WitnessUtxo, nil
+	}
+	return nil, fmt.Errorf("previous output is missing")
+}
+
+func resolveUtxoInput(chain vgcommon.Chain, prevOut *wire.TxOut, redeemScript []byte) (*utxoInput, error) {
+	pkScript := prevOut.PkScript
+	switch {
+	case txscript.IsPayToPubKeyHash(pkScript):
+		return &utxoInput{
+			scriptType: utxoScriptP2PKH,
+			prevOut:    prevOut,
+			pubKeyHash: pkScript[3:23],
+			subScript:  pkScript,
+		}, nil
+	case txscript.IsPayToWitnessPubKeyHash(pkScript):
+		if chain == vgcommon.Dogecoin {
+			return nil, fmt.Errorf("segwit outputs are not supported on %s", chain.String())
+		}
+		return &utxoInput{
+			scriptType: utxoScriptP2WPKH,
+			prevOut:    prevOut,
+			pubKeyHash: pkScript[2:22],
+			subScript:  pkScript,
+		}, nil
+	case txscript.IsPayToScriptHash(pkScript):
+		if chain == vgcommon.Dogecoin {
+			return nil, fmt.Errorf("segwit outputs are not supported on %s", chain.String())
+		}
+		if !txscript.IsPayToWitnessPubKeyHash(redeemScript) {
+			return nil, fmt.Errorf("only P2SH-wrapped P2WPKH redeem scripts are supported")
+		}
+		if !bytes.Equal(btcutil.Hash160(redeemScript), pkScript[2:22]) {
+			return nil, fmt.Errorf("redeem script does not match the previous output script")
+		}
+		return &utxoInput{
+			scriptType:   utxoScriptP2SHP2WPKH,
+			prevOut:      prevOut,
+			pubKeyHash:   redeemScript[2:22],
+			subScript:    redeemScript,
+			redeemScript: redeemScript,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported previous output script type %s", txscript.GetScriptClass(pkScript))
+	}
+}
+
+func (t *UtxoTx) Type() TxType {
+	return TxTypePsbt
+}
+
+// PolicyPayload returns the serialized unsigned transaction, the format the recipes BTC engine parses.
+func (t *UtxoTx) PolicyPayload() []byte {
+	var buf bytes.Buffer
+	// Serializing into a buffer can't fail
+	_ = t.Packet.UnsignedTx.SerializeNoWitness(&buf)
+	return buf.Bytes()
+}
+
+func (t *UtxoTx) Digest() string {
+	return t.Packet.UnsignedTx.TxHash().String()
+}
+
+// Fee returns the value of the spent outputs that is not paid to an output, in satoshis.
+func (t *UtxoTx) Fee() *big.Int {
+	fee := new(big.Int)
+	for _, in := range t.inputs {
+		fee.Add(fee, big.NewInt(in.prevOut.Value))
+	}
+	for _, out := range t.Packet.UnsignedTx.TxOut {
+		fee.Sub(fee, big.NewInt(out.Value))
+	}
+	return fee
+}
+
+// ExternalValue returns the value paid to outputs that don't belong to the vault, in satoshis.
+// Outputs paying back to a key of the inputs, in any of the supported script types, are change.
+func (t *UtxoTx) ExternalValue() *big.Int {
+	value := new(big.Int)
+	for _, out := range t.Packet.UnsignedTx.TxOut {
+		if !t.isChangeOutput(out.PkScript) {
+			value.Add(value, big.NewInt(out.Value))
+		}
+	}
+	return value
+}
+
+func (t *UtxoTx) isChangeOutput(pkScript []byte) bool {
+	for _, in := range t.inputs {
+		switch {
+		case txscript.IsPayToPubKeyHash(pkScript):
+			if bytes.Equal(pkScript[3:23], in.pubKeyHash) {
+				return true
+			}
+		case txscript.IsPayToWitnessPubKeyHash(pkScript):
+			if bytes.Equal(pkScript[2:22], in.pubKeyHash) {
+				return true
+			}
+		case txscript.IsPayToScriptHash(pkScript):
+			redeemScript := append([]byte{txscript.OP_0, txscript.OP_DATA_20}, in.pubKeyHash...)
+			if bytes.Equal(pkScript[2:22], btcutil.Hash160(redeemScript)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SigningHashes returns the sighash of every input, in input order.
+func (t *UtxoTx) SigningHashes() ([][]byte, error) {
+	hashes := make([][]byte, 0, len(t.inputs))
+	for i, in := range t.inputs {
+		var (
+			hash []byte
+			err  error
+		)
+		switch in.scriptType {
+		case utxoScriptP2PKH:
+			hash, err = txscript.CalcSignatureHash(in.subScript, txscript.SigHashAll, t.Packet.UnsignedTx, i)
+		default:
+			hash, err = txscript.CalcWitnessSigHash(
+				in.subScript,
+				t.sigHashes,
+				txscript.SigHashAll,
+				t.Packet.UnsignedTx,
+				i,
+				in.prevOut.Value,
+			)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute sighash for input %d: %w", i, err)
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, nil
+}
+
+// CheckOwner verifies that every input spends an output of the given compressed public key.
+func (t *UtxoTx) CheckOwner(pubKey []byte) error {
+	pubKeyHash := btcutil.Hash160(pubKey)
+	for i, in := range t.inputs {
+		if !bytes.Equal(in.pubKeyHash, pubKeyHash) {
+			return fmt.Errorf("input %d does not spend an output of the vault", i)
+		}
+	}
+	return nil
+}
+
+// Assemble applies one TSS signature per input, in input order, and returns the finalized
+// transaction. Every signature is verified against the vault public key.
+func (t *UtxoTx) Assemble(sigs []tss.KeysignResponse, pubKey []byte) (*wire.MsgTx, error) {
+	if len(sigs) != len(t.inputs) {
+		return nil, fmt.Errorf("expected %d signatures, got %d", len(t.inputs), len(sigs))
+	}
+	if err := t.CheckOwner(pubKey); err != nil {
+		return nil, err
+	}
+
+	key, err := btcec.ParsePubKey(pubKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vault public key: %w", err)
+	}
+	hashes, err := t.SigningHashes()
+	if err != nil {
+		return nil, err
+	}
+
+	// The signatures are added to a copy, the proposed packet stays unsigned
+	var buf bytes.Buffer
+	if err := t.Packet.Serialize(&buf); err != nil {
+		return nil, fmt.Errorf("failed to copy psbt: %w", err)
+	}
+	packet, err := psbt.NewFromRawBytes(&buf, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy psbt: %w", err)
+	}
+	updater, err := psbt.NewUpdater(packet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update psbt: %w", err)
+	}
+
+	for i, in := range t.inputs {
+		sig, er := decodeUtxoSignature(sigs[i])
+		if er != nil {
+			return nil, fmt.Errorf("invalid signature for input %d: %w", i, er)
+		}
+		if !sig.Verify(hashes[i], key) {
+			return nil, fmt.Errorf("signature for input %d does not verify against the vault public key", i)
+		}
+		sigBytes := append(sig.Serialize(), byte(txscript.SigHashAll))
+		if _, er := updater.Sign(i, sigBytes, pubKey, in.redeemScript, nil); er != nil {
+			return nil, fmt.Errorf("failed to add signature for input %d: %w", i, er)
+		}
+	}
+
+	if err := psbt.MaybeFinalizeAll(packet); err != nil {
+		return nil, fmt.Errorf("failed to finalize psbt: %w", err)
+	}
+	signedTx, err := psbt.Extract(packet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract signed transaction: %w", err)
+	}
+	return signedTx, nil
+}
+
+func decodeUtxoSignature(sig tss.KeysignResponse) (*ecdsa.Signature, error) {
+	rBytes, err := decodeSignatureComponent(sig.R, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature R: %w", err)
+	}
+	sBytes, err := decodeSignatureComponent(sig.S, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature S: %w", err)
+	}
+
+	var r, s btcec.ModNScalar
+	if overflow := r.SetByteSlice(rBytes); overflow || r.IsZero() {
+		return nil, fmt.Errorf("signature R is out of range")
+	}
+	if overflow := s.SetByteSlice(sBytes); overflow || s.IsZero() {
+		return nil, fmt.Errorf("signature S is out of range")
+	}
+	return ecdsa.NewSignature(&r, &s), nil
+}
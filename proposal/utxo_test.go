@@ -0,0 +1,250 @@
+package proposal
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/vultisig/mobile-tss-lib/tss"
+	vgcommon "github.com/vultisig/vultisig-go/common"
+)
+
+const utxoFixtureValue = 100_000
+
+// utxoFixture is a vault key sha256("utxo fixture") with a funding transaction paying it once in
+// every supported script type: P2PKH, P2WPKH and P2SH-P2WPKH, in that output order.
+type utxoFixture struct {
+	key          *btcec.PrivateKey
+	pubKey       []byte
+	funding      *wire.MsgTx
+	redeemScript []byte
+}
+
+func newUtxoFixture(t *testing.T) *utxoFixture {
+	t.Helper()
+	seed := sha256.Sum256([]byte("utxo fixture"))
+	key, _ := btcec.PrivKeyFromBytes(seed[:])
+	pubKey := key.PubKey().SerializeCompressed()
+	keyHash := btcutil.Hash160(pubKey)
+
+	p2pkh := mustBuildScript(t, txscript.NewScriptBuilder().
+		AddOp(txscript.OP_DUP).AddOp(txscript.OP_HASH160).AddData(keyHash).
+		AddOp(txscript.OP_EQUALVERIFY).AddOp(txscript.OP_CHECKSIG))
+	p2wpkh := mustBuildScript(t, txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(keyHash))
+	p2sh := mustBuildScript(t, txscript.NewScriptBuilder().
+		AddOp(txscript.OP_HASH160).AddData(btcutil.Hash160(p2wpkh)).AddOp(txscript.OP_EQUAL))
+
+	funding := wire.NewMsgTx(2)
+	funding.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 0}, nil, nil))
+	for _, pkScript := range [][]byte{p2pkh, p2wpkh, p2sh} {
+		funding.AddTxOut(wire.NewTxOut(utxoFixtureValue, pkScript))
+	}
+
+	return &utxoFixture{
+		key:          key,
+		pubKey:       pubKey,
+		funding:      funding,
+		redeemScript: p2wpkh,
+	}
+}
+
+// packet spends the given outputs of the funding transaction to a single external output.
+func (f *utxoFixture) packet(t *testing.T, outputs ...uint32) *psbt.Packet {
+	t.Helper()
+	fundingHash := f.funding.TxHash()
+	outPoints := make([]*wire.OutPoint, 0, len(outputs))
+	sequences := make([]uint32, 0, len(outputs))
+	for _, index := range outputs {
+		outPoints = append(outPoints, wire.NewOutPoint(&fundingHash, index))
+		sequences = append(sequences, wire.MaxTxInSequenceNum)
+	}
+	external := mustBuildScript(t, txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(bytes.Repeat([]byte{0x22}, 20)))
+	packet, err := psbt.New(outPoints, []*wire.TxOut{wire.NewTxOut(int64(len(outputs))*utxoFixtureValue-1_000, external)}, 2, 0, sequences)
+	if err != nil {
+		t.Fatalf("failed to create psbt: %v", err)
+	}
+
+	updater, err := psbt.NewUpdater(packet)
+	if err != nil {
+		t.Fatalf("failed to create updater: %v", err)
+	}
+	for i, index := range outputs {
+		switch index {
+		case 0:
+			err = updater.AddInNonWitnessUtxo(f.funding, i)
+		case 1:
+			err = updater.AddInWitnessUtxo(f.funding.TxOut[index], i)
+		case 2:
+			err = updater.AddInWitnessUtxo(f.funding.TxOut[index], i)
+			if err == nil {
+				err = updater.AddInRedeemScript(f.redeemScript, i)
+			}
+		}
+		if err != nil {
+			t.Fatalf("failed to update input %d: %v", i, err)
+		}
+	}
+	return packet
+}
+
+func (f *utxoFixture) sign(t *testing.T, hashes [][]byte) []tss.KeysignResponse {
+	t.Helper()
+	sigs := make([]tss.KeysignResponse, 0, len(hashes))
+	for _, hash := range hashes {
+		sig := ecdsa.Sign(f.key, hash)
+		r, s := sig.R(), sig.S()
+		rBytes, sBytes := r.Bytes(), s.Bytes()
+		sigs = append(sigs, tss.KeysignResponse{
+			R: hex.EncodeToString(rBytes[:]),
+			S: hex.EncodeToString(sBytes[:]),
+		})
+	}
+	return sigs
+}
+
+func serializePacket(t *testing.T, packet *psbt.Packet) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := packet.Serialize(&buf); err != nil {
+		t.Fatalf("failed to serialize psbt: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func mustBuildScript(t *testing.T, builder *txscript.ScriptBuilder) []byte {
+	t.Helper()
+	script, err := builder.Script()
+	if err != nil {
+		t.Fatalf("failed to build script: %v", err)
+	}
+	return script
+}
+
+// TestUtxoAssemble signs the sighash of every input and runs the finalized transaction through
+// the script engine, which recomputes the sighashes on its own.
+func TestUtxoAssemble(t *testing.T) {
+	f := newUtxoFixture(t)
+	tests := []struct {
+		name    string
+		outputs []uint32
+	}{
+		{name: "p2pkh", outputs: []uint32{0}},
+		{name: "p2wpkh", outputs: []uint32{1}},
+		{name: "p2sh-p2wpkh", outputs: []uint32{2}},
+		{name: "mixed", outputs: []uint32{0, 1, 2}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tx, err := DecodeUtxoTx(vgcommon.Bitcoin, serializePacket(t, f.packet(t, tc.outputs...)))
+			if err != nil {
+				t.Fatalf("DecodeUtxoTx() error = %v", err)
+			}
+			if fee := tx.Fee().Int64(); fee != 1_000 {
+				t.Errorf("Fee() = %d, want 1000", fee)
+			}
+			hashes, err := tx.SigningHashes()
+			if err != nil {
+				t.Fatalf("SigningHashes() error = %v", err)
+			}
+			if len(hashes) != len(tc.outputs) {
+				t.Fatalf("SigningHashes() returned %d hashes, want %d", len(hashes), len(tc.outputs))
+			}
+
+			signedTx, err := tx.Assemble(f.sign(t, hashes), f.pubKey)
+			if err != nil {
+				t.Fatalf("Assemble() error = %v", err)
+			}
+			stripped := signedTx.Copy()
+			for _, txIn := range stripped.TxIn {
+				txIn.SignatureScript, txIn.Witness = nil, nil
+			}
+			if stripped.TxHash() != tx.Packet.UnsignedTx.TxHash() {
+				t.Error("Assemble() changed the transaction")
+			}
+			if len(tx.Packet.Inputs[0].PartialSigs) != 0 {
+				t.Error("Assemble() signed the proposed packet")
+			}
+
+			prevOuts := txscript.NewMultiPrevOutFetcher(nil)
+			for i, index := range tc.outputs {
+				prevOuts.AddPrevOut(signedTx.TxIn[i].PreviousOutPoint, f.funding.TxOut[index])
+			}
+			sigHashes := txscript.NewTxSigHashes(signedTx, prevOuts)
+			for i, index := range tc.outputs {
+				prevOut := f.funding.TxOut[index]
+				vm, err := txscript.NewEngine(prevOut.PkScript, signedTx, i, txscript.StandardVerifyFlags, nil, sigHashes, prevOut.Value, prevOuts)
+				if err != nil {
+					t.Fatalf("input %d: failed to create script engine: %v", i, err)
+				}
+				if err := vm.Execute(); err != nil {
+					t.Errorf("input %d: script does not verify: %v", i, err)
+				}
+			}
+		})
+	}
+
+	tx, err := DecodeUtxoTx(vgcommon.Bitcoin, serializePacket(t, f.packet(t, 1)))
+	if err != nil {
+		t.Fatalf("DecodeUtxoTx() error = %v", err)
+	}
+	hashes, err := tx.SigningHashes()
+	if err != nil {
+		t.Fatalf("SigningHashes() error = %v", err)
+	}
+	sigs := f.sign(t, hashes)
+	if _, err := tx.Assemble(sigs, f.key.PubKey().SerializeUncompressed()); err == nil {
+		t.Error("Assemble() accepted a key that does not own the inputs")
+	}
+	if _, err := tx.Assemble(append(sigs, sigs...), f.pubKey); err == nil {
+		t.Error("Assemble() accepted more signatures than inputs")
+	}
+	if _, err := tx.Assemble(f.sign(t, [][]byte{bytes.Repeat([]byte{0x01}, 32)}), f.pubKey); err == nil {
+		t.Error("Assemble() accepted a signature of another hash")
+	}
+}
+
+func TestDecodeUtxoTxRejects(t *testing.T) {
+	f := newUtxoFixture(t)
+
+	sighashSingle := f.packet(t, 1)
+	sighashSingle.Inputs[0].SighashType = txscript.SigHashSingle
+
+	finalized := f.packet(t, 1)
+	finalized.Inputs[0].FinalScriptWitness = []byte{0x00}
+
+	legacyWitnessUtxo := f.packet(t, 0)
+	legacyWitnessUtxo.Inputs[0].NonWitnessUtxo = nil
+	legacyWitnessUtxo.Inputs[0].WitnessUtxo = f.funding.TxOut[0]
+
+	missingUtxo := f.packet(t, 1)
+	missingUtxo.Inputs[0].WitnessUtxo = nil
+
+	tests := []struct {
+		name    string
+		chain   vgcommon.Chain
+		payload []byte
+	}{
+		{name: "not a psbt", chain: vgcommon.Bitcoin, payload: []byte("psbt")},
+		{name: "trailing bytes", chain: vgcommon.Bitcoin, payload: append(serializePacket(t, f.packet(t, 1)), 0x00)},
+		{name: "sighash single", chain: vgcommon.Bitcoin, payload: serializePacket(t, sighashSingle)},
+		{name: "finalized input", chain: vgcommon.Bitcoin, payload: serializePacket(t, finalized)},
+		{name: "p2pkh without previous tx", chain: vgcommon.Bitcoin, payload: serializePacket(t, legacyWitnessUtxo)},
+		{name: "missing utxo", chain: vgcommon.Bitcoin, payload: serializePacket(t, missingUtxo)},
+		{name: "segwit on dogecoin", chain: vgcommon.Dogecoin, payload: serializePacket(t, f.packet(t, 1))},
+		{name: "bitcoin cash", chain: vgcommon.BitcoinCash, payload: serializePacket(t, f.packet(t, 0))},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := DecodeUtxoTx(tc.chain, tc.payload); err == nil {
+				t.Error("DecodeUtxoTx() accepted an invalid psbt")
+			}
+		})
+	}
+}
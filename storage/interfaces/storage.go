@@ -2,26 +2,118 @@ package interfaces
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math/big"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/vultisig/pluginagent/types"
 	vtypes "github.com/vultisig/verifier/types"
+	"github.com/vultisig/vultisig-go/common"
 )
 
+var (
+	ErrPolicyNotFound   = errors.New("policy not found")
+	ErrProposalNotFound = errors.New("proposal not found")
+	// ErrPolicyRevisionNotFound is returned for revisions a policy doesn't have
+	ErrPolicyRevisionNotFound = errors.New("policy revision not found")
+	// ErrProposalNotPending is returned when a proposal is not, or no longer, waiting for approval
+	ErrProposalNotPending = errors.New("proposal is not pending approval")
+	// ErrNonceReservationNotFound is returned for unknown reservations and reservations already
+	// claimed by a proposal
+	ErrNonceReservationNotFound = errors.New("nonce reservation not found")
+)
+
+// PolicyVersionConflictError is returned when an update doesn't submit the version that follows
+// the current version of the policy, e.g. because another update was stored first.
+type PolicyVersionConflictError struct {
+	PolicyID         uuid.UUID
+	CurrentVersion   int
+	SubmittedVersion int
+}
+
+func (e *PolicyVersionConflictError) Error() string {
+	return fmt.Sprintf("policy %s is at version %d, updates must submit version %d, got %d",
+		e.PolicyID, e.CurrentVersion, e.CurrentVersion+1, e.SubmittedVersion)
+}
+
 // DatabaseStorage defines the interface for database storage operations
 type DatabaseStorage interface {
 	Close() error
 
 	GetPluginPolicy(ctx context.Context, id uuid.UUID) (*vtypes.PluginPolicy, error)
-	GetAllPluginPolicies(ctx context.Context, publicKey string, pluginID vtypes.PluginID, onlyActive bool) ([]vtypes.PluginPolicy, error)
+	// ListPluginPolicies lists policies newest first, starting after the cursor of the filter.
+	ListPluginPolicies(ctx context.Context, filter types.PluginPolicyFilter) ([]types.PluginPolicyRecord, error)
+	// DeletePluginPolicy soft deletes the policy, deleted policies are left out of every other read
+	// but GetDeletedPluginPolicy.
 	DeletePluginPolicy(ctx context.Context, id uuid.UUID) error
+	GetDeletedPluginPolicy(ctx context.Context, id uuid.UUID) (*types.PluginPolicyRecord, error)
+	// RestorePluginPolicy undeletes the policy if it was deleted after deletedAfter.
+	RestorePluginPolicy(ctx context.Context, id uuid.UUID, deletedAfter time.Time) error
+	// PurgeDeletedPluginPolicies removes the policies deleted before deletedBefore and returns them.
+	PurgeDeletedPluginPolicies(ctx context.Context, deletedBefore time.Time) ([]types.PluginPolicyRecord, error)
 	InsertPluginPolicy(ctx context.Context, policy vtypes.PluginPolicy) (*vtypes.PluginPolicy, error)
+	// UpdatePluginPolicy stores the policy if its version follows the stored version, or fails with
+	// a PolicyVersionConflictError.
 	UpdatePluginPolicy(ctx context.Context, policy vtypes.PluginPolicy) (*vtypes.PluginPolicy, error)
+	// InsertPluginPolicyRevision records the policy as stored as its next revision, within the
+	// transaction that stored it.
+	InsertPluginPolicyRevision(ctx context.Context, policyID uuid.UUID) (*types.PluginPolicyRevision, error)
+	// ListPluginPolicyRevisions lists the revisions of the policy, oldest first.
+	ListPluginPolicyRevisions(ctx context.Context, policyID uuid.UUID) ([]types.PluginPolicyRevision, error)
+	GetPluginPolicyRevision(ctx context.Context, policyID uuid.UUID, revision int) (*types.PluginPolicyRevision, error)
 
 	InsertEvent(ctx context.Context, event *types.SystemEvent) (int64, error)
 	GetEventsAfterTimestamp(ctx context.Context, createdAt time.Time) ([]types.SystemEvent, error)
 
+	// InsertProposal stores the proposal unless a proposal that has not failed already holds its
+	// idempotency key, in which case that proposal is returned and created is false.
+	InsertProposal(ctx context.Context, proposal types.Proposal) (p *types.Proposal, created bool, err error)
+	GetProposal(ctx context.Context, id uuid.UUID) (*types.Proposal, error)
+	ListProposals(ctx context.Context, filter types.ProposalFilter) ([]types.Proposal, error)
+	UpdateProposalStatus(ctx context.Context, id uuid.UUID, status types.ProposalStatus, errMsg *string) error
+	UpdateProposalSigned(ctx context.Context, proposal types.Proposal) error
+	// ResolvePendingApproval moves a proposal out of pending_approval if its approval hasn't
+	// expired at now, or fails with ErrProposalNotPending.
+	ResolvePendingApproval(
+		ctx context.Context,
+		id uuid.UUID,
+		status types.ProposalStatus,
+		errMsg *string,
+		now time.Time,
+	) (*types.Proposal, error)
+	// ExpirePendingApprovals fails the proposals whose approval expired at now and returns them.
+	ExpirePendingApprovals(ctx context.Context, now time.Time, errMsg string) ([]types.Proposal, error)
+	// FailStaleSigningProposals fails the proposals that have been signing for longer than
+	// staleAfter and returns them.
+	FailStaleSigningProposals(ctx context.Context, staleAfter time.Duration, errMsg string) ([]types.Proposal, error)
+
+	// LockPolicyLedgers serializes spend and execution accounting of the policy until the
+	// transaction ends.
+	LockPolicyLedgers(ctx context.Context, policyID uuid.UUID) error
+	// GetSpendUsage sums the entries of the token recorded within the window.
+	GetSpendUsage(ctx context.Context, policyID uuid.UUID, chain common.Chain, token string, window time.Duration) (*big.Int, error)
+	InsertSpendEntry(ctx context.Context, entry types.SpendEntry) error
+	// GetPolicyExecutionTimes lists the executions of the policy within the window, oldest first.
+	GetPolicyExecutionTimes(ctx context.Context, policyID uuid.UUID, window time.Duration) ([]time.Time, error)
+	// InsertPolicyExecution records the transaction at txIndex of the proposal as an execution.
+	InsertPolicyExecution(ctx context.Context, proposalID, policyID uuid.UUID, txIndex int) error
+
+	// LockNonces serializes nonce accounting of the address until the transaction ends.
+	LockNonces(ctx context.Context, chain common.Chain, address string) error
+	// ListHeldNonces lists the nonces of the address from fromNonce on that are held by a
+	// reservation unexpired at now, by a proposal signed within signedHold or by a proposal that
+	// is neither signed nor failed, lowest first.
+	ListHeldNonces(ctx context.Context, chain common.Chain, address string, fromNonce uint64, now time.Time, signedHold time.Duration) ([]types.NonceEntry, error)
+	// UpsertNonceEntry records the holder of a nonce, replacing its previous holder.
+	UpsertNonceEntry(ctx context.Context, entry types.NonceEntry) error
+	// DeleteNoncesBelow prunes the entries of nonces the chain has already confirmed.
+	DeleteNoncesBelow(ctx context.Context, chain common.Chain, address string, nonce uint64) error
+	// DeleteNonceReservation releases a reservation of the policy that no proposal has claimed,
+	// or fails with ErrNonceReservationNotFound.
+	DeleteNonceReservation(ctx context.Context, id uuid.UUID, policyID uuid.UUID) error
+
 	// Transaction support
 	WithTx(ctx context.Context, fn func(DatabaseStorage) error) error
 }
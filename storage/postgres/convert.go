@@ -1,11 +1,16 @@
 package postgres
 
 import (
-	"strconv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/vultisig/mobile-tss-lib/tss"
 	vtypes "github.com/vultisig/verifier/types"
+	"github.com/vultisig/vultisig-go/common"
 
 	"github.com/vultisig/pluginagent/storage/postgres/queries"
 	"github.com/vultisig/pluginagent/types"
@@ -17,17 +22,12 @@ func toVTypesPluginPolicy(row queries.GetPluginPolicyRow) (*vtypes.PluginPolicy,
 		return nil, err
 	}
 
-	policyVersion, err := strconv.Atoi(row.PolicyVersion)
-	if err != nil {
-		return nil, err
-	}
-
 	return &vtypes.PluginPolicy{
 		ID:            id,
 		PublicKey:     row.PublicKey,
 		PluginID:      vtypes.PluginID(row.PluginID),
 		PluginVersion: row.PluginVersion,
-		PolicyVersion: policyVersion,
+		PolicyVersion: int(row.PolicyVersion),
 		Signature:     row.Signature,
 		Active:        row.Active,
 		Recipe:        row.Recipe,
@@ -40,17 +40,12 @@ func toVTypesPluginPolicyFromInsert(row queries.InsertPluginPolicyRow) (*vtypes.
 		return nil, err
 	}
 
-	policyVersion, err := strconv.Atoi(row.PolicyVersion)
-	if err != nil {
-		return nil, err
-	}
-
 	return &vtypes.PluginPolicy{
 		ID:            id,
 		PublicKey:     row.PublicKey,
 		PluginID:      vtypes.PluginID(row.PluginID),
 		PluginVersion: row.PluginVersion,
-		PolicyVersion: policyVersion,
+		PolicyVersion: int(row.PolicyVersion),
 		Signature:     row.Signature,
 		Active:        row.Active,
 		Recipe:        row.Recipe,
@@ -63,43 +58,56 @@ func toVTypesPluginPolicyFromUpdate(row queries.UpdatePluginPolicyRow) (*vtypes.
 		return nil, err
 	}
 
-	policyVersion, err := strconv.Atoi(row.PolicyVersion)
-	if err != nil {
-		return nil, err
-	}
-
 	return &vtypes.PluginPolicy{
 		ID:            id,
 		PublicKey:     row.PublicKey,
 		PluginID:      vtypes.PluginID(row.PluginID),
 		PluginVersion: row.PluginVersion,
-		PolicyVersion: policyVersion,
+		PolicyVersion: int(row.PolicyVersion),
 		Signature:     row.Signature,
 		Active:        row.Active,
 		Recipe:        row.Recipe,
 	}, nil
 }
 
-func toVTypesPluginPolicyFromGetAll(row queries.GetAllPluginPoliciesRow) (*vtypes.PluginPolicy, error) {
+func toTypesPluginPolicyRecord(row queries.ListPluginPoliciesRow) (*types.PluginPolicyRecord, error) {
 	id, err := uuidFromPgUUID(row.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	policyVersion, err := strconv.Atoi(row.PolicyVersion)
+	return &types.PluginPolicyRecord{
+		PluginPolicy: vtypes.PluginPolicy{
+			ID:            id,
+			PublicKey:     row.PublicKey,
+			PluginID:      vtypes.PluginID(row.PluginID),
+			PluginVersion: row.PluginVersion,
+			PolicyVersion: int(row.PolicyVersion),
+			Signature:     row.Signature,
+			Active:        row.Active,
+			Recipe:        row.Recipe,
+		},
+		Deleted:   row.Deleted,
+		CreatedAt: row.CreatedAt.Time,
+		DeletedAt: timeFromPgTimestamptz(row.DeletedAt),
+	}, nil
+}
+
+func toTypesPluginPolicyRevision(row queries.PluginPolicyRevision) (*types.PluginPolicyRevision, error) {
+	policyID, err := uuidFromPgUUID(row.PolicyID)
 	if err != nil {
 		return nil, err
 	}
 
-	return &vtypes.PluginPolicy{
-		ID:            id,
-		PublicKey:     row.PublicKey,
-		PluginID:      vtypes.PluginID(row.PluginID),
+	return &types.PluginPolicyRevision{
+		PolicyID:      policyID,
+		Revision:      int(row.Revision),
 		PluginVersion: row.PluginVersion,
-		PolicyVersion: policyVersion,
+		PolicyVersion: int(row.PolicyVersion),
 		Signature:     row.Signature,
 		Active:        row.Active,
 		Recipe:        row.Recipe,
+		CreatedAt:     row.CreatedAt.Time,
 	}, nil
 }
 
@@ -128,6 +136,157 @@ func toTypesSystemEvent(row queries.SystemEvent) (*types.SystemEvent, error) {
 	}, nil
 }
 
+func toTypesProposal(row queries.Proposal) (*types.Proposal, error) {
+	id, err := uuidFromPgUUID(row.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	policyID, err := uuidFromPgUUID(row.PolicyID)
+	if err != nil {
+		return nil, err
+	}
+
+	chain, err := common.FromString(row.Chain)
+	if err != nil {
+		return nil, err
+	}
+
+	var signatures map[string]tss.KeysignResponse
+	if len(row.Signatures) > 0 {
+		if err := json.Unmarshal(row.Signatures, &signatures); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal signatures: %w", err)
+		}
+	}
+
+	var batch []types.ProposalTx
+	if len(row.Batch) > 0 {
+		if err := json.Unmarshal(row.Batch, &batch); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal batch: %w", err)
+		}
+	}
+
+	return &types.Proposal{
+		ID:                id,
+		PolicyID:          policyID,
+		PublicKey:         row.PublicKey,
+		Chain:             chain,
+		TxHex:             row.TxHex,
+		Broadcast:         row.Broadcast,
+		Status:            types.ProposalStatus(row.Status),
+		IdempotencyKey:    row.IdempotencyKey,
+		RequestDigest:     row.RequestDigest,
+		ApprovalExpiresAt: timeFromPgTimestamp(row.ApprovalExpiresAt),
+		Batch:             batch,
+		CallbackURL:       textFromPgText(row.CallbackUrl),
+		Signatures:        signatures,
+		SignedTxHex:       textFromPgText(row.SignedTxHex),
+		TxHash:            textFromPgText(row.TxHash),
+		BroadcastTxHash:   textFromPgText(row.BroadcastTxHash),
+		BroadcastError:    textFromPgText(row.BroadcastError),
+		Error:             textFromPgText(row.Error),
+		CreatedAt:         row.CreatedAt.Time,
+		UpdatedAt:         row.UpdatedAt.Time,
+	}, nil
+}
+
+func toTypesNonceEntry(row queries.NonceLedger) (*types.NonceEntry, error) {
+	chain, err := common.FromString(row.Chain)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &types.NonceEntry{
+		Chain:     chain,
+		Address:   row.Address,
+		Nonce:     uint64(row.Nonce),
+		ExpiresAt: timeFromPgTimestamp(row.ExpiresAt),
+		CreatedAt: row.CreatedAt.Time,
+	}
+	if row.PolicyID.Valid {
+		entry.PolicyID = uuid.UUID(row.PolicyID.Bytes)
+	}
+	if row.ReservationID.Valid {
+		id := uuid.UUID(row.ReservationID.Bytes)
+		entry.ReservationID = &id
+	}
+	if row.ProposalID.Valid {
+		id := uuid.UUID(row.ProposalID.Bytes)
+		entry.ProposalID = &id
+	}
+	return entry, nil
+}
+
+// batchToJSON encodes the transactions of a batch proposal, single transaction proposals are
+// stored without a batch.
+func batchToJSON(batch []types.ProposalTx) ([]byte, error) {
+	if len(batch) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal batch: %w", err)
+	}
+	return data, nil
+}
+
+// policyVersionToInt32 converts the version for the INTEGER column, it never wraps.
+func policyVersionToInt32(version int) (int32, error) {
+	if version < 0 || version > math.MaxInt32 {
+		return 0, fmt.Errorf("policy version %d out of range", version)
+	}
+	return int32(version), nil
+}
+
+func textToPgText(s *string) pgtype.Text {
+	if s == nil {
+		return pgtype.Text{}
+	}
+	return pgtype.Text{String: *s, Valid: true}
+}
+
+func textFromPgText(t pgtype.Text) *string {
+	if !t.Valid {
+		return nil
+	}
+	return &t.String
+}
+
+func timeToPgTimestamp(t *time.Time) pgtype.Timestamp {
+	if t == nil {
+		return pgtype.Timestamp{}
+	}
+	return pgtype.Timestamp{Time: *t, Valid: true}
+}
+
+func timeToPgTimestamptz(t *time.Time) pgtype.Timestamptz {
+	if t == nil {
+		return pgtype.Timestamptz{}
+	}
+	return pgtype.Timestamptz{Time: *t, Valid: true}
+}
+
+func boolToPgBool(b *bool) pgtype.Bool {
+	if b == nil {
+		return pgtype.Bool{}
+	}
+	return pgtype.Bool{Bool: *b, Valid: true}
+}
+
+func timeFromPgTimestamptz(t pgtype.Timestamptz) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func timeFromPgTimestamp(t pgtype.Timestamp) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
 func uuidToPgUUID(id uuid.UUID) pgtype.UUID {
 	return pgtype.UUID{
 		Bytes: id,
@@ -135,6 +294,13 @@ func uuidToPgUUID(id uuid.UUID) pgtype.UUID {
 	}
 }
 
+func optionalUUIDToPgUUID(id *uuid.UUID) pgtype.UUID {
+	if id == nil {
+		return pgtype.UUID{}
+	}
+	return uuidToPgUUID(*id)
+}
+
 func uuidFromPgUUID(pguuid pgtype.UUID) (uuid.UUID, error) {
 	if !pguuid.Valid {
 		return uuid.Nil, nil
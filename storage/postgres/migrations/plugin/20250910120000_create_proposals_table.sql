@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TYPE proposal_status AS ENUM ('queued', 'signing', 'signed', 'failed');
+
+CREATE TABLE IF NOT EXISTS proposals (
+    id UUID PRIMARY KEY,
+    policy_id UUID NOT NULL,
+    chain TEXT NOT NULL,
+    tx_hex TEXT NOT NULL,
+    broadcast BOOLEAN NOT NULL DEFAULT false,
+    status proposal_status NOT NULL DEFAULT 'queued',
+    signatures JSONB,
+    signed_tx_hex TEXT,
+    tx_hash TEXT,
+    broadcast_tx_hash TEXT,
+    broadcast_error TEXT,
+    error TEXT,
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_proposals_policy_id ON proposals (policy_id);
+CREATE INDEX IF NOT EXISTS idx_proposals_status ON proposals (status);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS proposals;
+DROP TYPE IF EXISTS proposal_status;
+-- +goose StatementEnd
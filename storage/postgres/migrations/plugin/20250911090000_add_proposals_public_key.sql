@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE proposals ADD COLUMN public_key TEXT NOT NULL DEFAULT '';
+
+UPDATE proposals
+SET public_key = plugin_policies.public_key
+FROM plugin_policies
+WHERE plugin_policies.id = proposals.policy_id;
+
+CREATE INDEX IF NOT EXISTS idx_proposals_public_key ON proposals (public_key);
+CREATE INDEX IF NOT EXISTS idx_proposals_created_at ON proposals (created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_proposals_created_at;
+DROP INDEX IF EXISTS idx_proposals_public_key;
+ALTER TABLE proposals DROP COLUMN IF EXISTS public_key;
+-- +goose StatementEnd
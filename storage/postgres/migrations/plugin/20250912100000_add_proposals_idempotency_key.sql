@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE proposals ADD COLUMN idempotency_key TEXT;
+UPDATE proposals SET idempotency_key = id::text WHERE idempotency_key IS NULL;
+ALTER TABLE proposals ALTER COLUMN idempotency_key SET NOT NULL;
+
+-- Failed proposals do not block a retry of the same transaction
+CREATE UNIQUE INDEX IF NOT EXISTS idx_proposals_idempotency_key
+    ON proposals (idempotency_key)
+    WHERE status <> 'failed';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_proposals_idempotency_key;
+ALTER TABLE proposals DROP COLUMN IF EXISTS idempotency_key;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS spend_ledger (
+    proposal_id UUID NOT NULL REFERENCES proposals (id) ON DELETE CASCADE,
+    policy_id UUID NOT NULL,
+    chain TEXT NOT NULL,
+    token TEXT NOT NULL,
+    amount NUMERIC(78, 0) NOT NULL,
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (proposal_id, token)
+);
+
+CREATE INDEX IF NOT EXISTS idx_spend_ledger_window ON spend_ledger (policy_id, chain, token, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS spend_ledger;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TYPE proposal_status ADD VALUE 'pending_approval';
+
+ALTER TYPE system_event_type ADD VALUE 'proposal_pending_approval';
+ALTER TYPE system_event_type ADD VALUE 'proposal_approved';
+ALTER TYPE system_event_type ADD VALUE 'proposal_rejected';
+ALTER TYPE system_event_type ADD VALUE 'proposal_approval_expired';
+
+ALTER TABLE proposals ADD COLUMN approval_expires_at TIMESTAMP WITHOUT TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_proposals_approval_expires_at
+    ON proposals (approval_expires_at)
+    WHERE approval_expires_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_proposals_approval_expires_at;
+ALTER TABLE proposals DROP COLUMN IF EXISTS approval_expires_at;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Transactions of batch proposals with their signing outcome, NULL for single transactions
+ALTER TABLE proposals ADD COLUMN batch JSONB;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE proposals DROP COLUMN IF EXISTS batch;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS nonce_ledger (
+    chain TEXT NOT NULL,
+    address TEXT NOT NULL,
+    nonce BIGINT NOT NULL,
+    reservation_id UUID,
+    proposal_id UUID REFERENCES proposals (id) ON DELETE CASCADE,
+    expires_at TIMESTAMP WITHOUT TIME ZONE,
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (chain, address, nonce)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_nonce_ledger_reservation_id ON nonce_ledger (reservation_id) WHERE reservation_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS nonce_ledger;
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE proposals ADD COLUMN callback_url TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE proposals DROP COLUMN IF EXISTS callback_url;
+-- +goose StatementEnd
@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TYPE system_event_type ADD VALUE 'policy_restored';
+ALTER TYPE system_event_type ADD VALUE 'policy_purged';
+
+ALTER TABLE plugin_policies ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- Deleted policies can be restored, every other change to them is still rejected
+CREATE OR REPLACE FUNCTION prevent_update_if_policy_deleted()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF OLD.deleted = true AND (
+        NEW.deleted = true AND NEW.active = true
+        OR NEW.public_key IS DISTINCT FROM OLD.public_key
+        OR NEW.plugin_id IS DISTINCT FROM OLD.plugin_id
+        OR NEW.plugin_version IS DISTINCT FROM OLD.plugin_version
+        OR NEW.policy_version IS DISTINCT FROM OLD.policy_version
+        OR NEW.signature IS DISTINCT FROM OLD.signature
+        OR NEW.recipe IS DISTINCT FROM OLD.recipe
+    ) THEN
+        RAISE EXCEPTION 'Cannot update a deleted policy';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Policies deleted before deletion times were recorded get the full grace period
+UPDATE plugin_policies SET deleted_at = NOW() WHERE deleted = true AND deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_plugin_policies_deleted_at ON plugin_policies (deleted_at) WHERE deleted = true;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_plugin_policies_deleted_at;
+
+CREATE OR REPLACE FUNCTION prevent_update_if_policy_deleted()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF OLD.deleted = true THEN
+        RAISE EXCEPTION 'Cannot update a deleted policy';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE plugin_policies DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
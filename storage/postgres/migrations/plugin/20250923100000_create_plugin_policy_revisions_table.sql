@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS plugin_policy_revisions (
+    policy_id UUID NOT NULL REFERENCES plugin_policies (id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    plugin_version TEXT NOT NULL,
+    policy_version TEXT NOT NULL,
+    signature TEXT NOT NULL,
+    active BOOLEAN NOT NULL,
+    recipe TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (policy_id, revision)
+);
+
+-- Existing policies start their history with the policy as stored
+INSERT INTO plugin_policy_revisions (policy_id, revision, plugin_version, policy_version, signature, active, recipe, created_at)
+SELECT id, 1, plugin_version, policy_version, signature, active, recipe, updated_at
+FROM plugin_policies
+ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS plugin_policy_revisions;
+-- +goose StatementEnd
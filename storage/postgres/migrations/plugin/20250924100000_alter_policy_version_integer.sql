@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Policy versions are signed along with the policy, a version that isn't an integer can't be
+-- converted without invalidating the signature. The migration fails listing them, they have to be
+-- fixed by hand before it is run again.
+DO $$
+DECLARE
+    policies TEXT;
+    revisions TEXT;
+BEGIN
+    SELECT string_agg(format('%s (%L)', id, policy_version), ', ' ORDER BY id) INTO policies
+    FROM plugin_policies
+    WHERE CASE WHEN policy_version ~ '^[0-9]{1,10}$' THEN policy_version::bigint > 2147483647 ELSE true END;
+
+    SELECT string_agg(format('%s revision %s (%L)', policy_id, revision, policy_version), ', ' ORDER BY policy_id, revision) INTO revisions
+    FROM plugin_policy_revisions
+    WHERE CASE WHEN policy_version ~ '^[0-9]{1,10}$' THEN policy_version::bigint > 2147483647 ELSE true END;
+
+    IF policies IS NOT NULL OR revisions IS NOT NULL THEN
+        RAISE EXCEPTION 'policy_version is not an integer for policies: %; revisions: %',
+            coalesce(policies, 'none'), coalesce(revisions, 'none');
+    END IF;
+END $$;
+
+ALTER TABLE plugin_policies ALTER COLUMN policy_version TYPE INTEGER USING policy_version::integer;
+ALTER TABLE plugin_policy_revisions ALTER COLUMN policy_version TYPE INTEGER USING policy_version::integer;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE plugin_policy_revisions ALTER COLUMN policy_version TYPE TEXT USING policy_version::text;
+ALTER TABLE plugin_policies ALTER COLUMN policy_version TYPE TEXT USING policy_version::text;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Reservations can only be released through the policy they were made for
+ALTER TABLE nonce_ledger ADD COLUMN IF NOT EXISTS policy_id UUID;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE nonce_ledger DROP COLUMN IF EXISTS policy_id;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Revisions are the approval history of a policy, they outlive the policy when it is purged
+ALTER TABLE plugin_policy_revisions DROP CONSTRAINT IF EXISTS plugin_policy_revisions_policy_id_fkey;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM plugin_policy_revisions
+WHERE policy_id NOT IN (SELECT id FROM plugin_policies);
+ALTER TABLE plugin_policy_revisions
+    ADD CONSTRAINT plugin_policy_revisions_policy_id_fkey
+    FOREIGN KEY (policy_id) REFERENCES plugin_policies (id) ON DELETE CASCADE;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Digest of the request a proposal was created from, a reused idempotency key must match it
+ALTER TABLE proposals ADD COLUMN IF NOT EXISTS request_digest TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE proposals DROP COLUMN IF EXISTS request_digest;
+-- +goose StatementEnd
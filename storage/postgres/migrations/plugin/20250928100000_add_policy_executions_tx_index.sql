@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Every transaction of a batch proposal is an execution of its own
+ALTER TABLE policy_executions ADD COLUMN IF NOT EXISTS tx_index INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE policy_executions DROP CONSTRAINT IF EXISTS policy_executions_pkey;
+ALTER TABLE policy_executions ADD PRIMARY KEY (proposal_id, tx_index);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM policy_executions WHERE tx_index > 0;
+ALTER TABLE policy_executions DROP CONSTRAINT IF EXISTS policy_executions_pkey;
+ALTER TABLE policy_executions DROP COLUMN IF EXISTS tx_index;
+ALTER TABLE policy_executions ADD PRIMARY KEY (proposal_id);
+-- +goose StatementEnd
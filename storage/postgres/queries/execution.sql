@@ -0,0 +1,13 @@
+-- name: InsertPolicyExecution :exec
+INSERT INTO policy_executions (
+    proposal_id, policy_id, tx_index
+) VALUES ($1, $2, $3);
+
+-- name: GetPolicyExecutionTimes :many
+SELECT e.created_at
+FROM policy_executions e
+JOIN proposals p ON p.id = e.proposal_id
+WHERE e.policy_id = sqlc.arg('policy_id')
+  AND p.status <> 'failed'
+  AND e.created_at > CURRENT_TIMESTAMP - make_interval(secs => sqlc.arg('window_seconds')::double precision)
+ORDER BY e.created_at ASC;
@@ -11,13 +11,64 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type ProposalStatus string
+
+const (
+	ProposalStatusQueued          ProposalStatus = "queued"
+	ProposalStatusSigning         ProposalStatus = "signing"
+	ProposalStatusSigned          ProposalStatus = "signed"
+	ProposalStatusFailed          ProposalStatus = "failed"
+	ProposalStatusPendingApproval ProposalStatus = "pending_approval"
+)
+
+func (e *ProposalStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ProposalStatus(s)
+	case string:
+		*e = ProposalStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ProposalStatus: %T", src)
+	}
+	return nil
+}
+
+type NullProposalStatus struct {
+	ProposalStatus ProposalStatus
+	Valid          bool // Valid is true if ProposalStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullProposalStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.ProposalStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ProposalStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullProposalStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ProposalStatus), nil
+}
+
 type SystemEventType string
 
 const (
-	SystemEventTypeVaultReshared SystemEventType = "vault_reshared"
-	SystemEventTypeVaultDeleted  SystemEventType = "vault_deleted"
-	SystemEventTypePolicyCreated SystemEventType = "policy_created"
-	SystemEventTypePolicyDeleted SystemEventType = "policy_deleted"
+	SystemEventTypeVaultReshared           SystemEventType = "vault_reshared"
+	SystemEventTypeVaultDeleted            SystemEventType = "vault_deleted"
+	SystemEventTypePolicyCreated           SystemEventType = "policy_created"
+	SystemEventTypePolicyDeleted           SystemEventType = "policy_deleted"
+	SystemEventTypePolicyRestored          SystemEventType = "policy_restored"
+	SystemEventTypePolicyPurged            SystemEventType = "policy_purged"
+	SystemEventTypeProposalPendingApproval SystemEventType = "proposal_pending_approval"
+	SystemEventTypeProposalApproved        SystemEventType = "proposal_approved"
+	SystemEventTypeProposalRejected        SystemEventType = "proposal_rejected"
+	SystemEventTypeProposalApprovalExpired SystemEventType = "proposal_approval_expired"
 )
 
 func (e *SystemEventType) Scan(src interface{}) error {
@@ -55,16 +106,80 @@ func (ns NullSystemEventType) Value() (driver.Value, error) {
 	return string(ns.SystemEventType), nil
 }
 
+type NonceLedger struct {
+	Chain         string
+	Address       string
+	Nonce         int64
+	ReservationID pgtype.UUID
+	ProposalID    pgtype.UUID
+	ExpiresAt     pgtype.Timestamp
+	CreatedAt     pgtype.Timestamp
+	PolicyID      pgtype.UUID
+}
+
 type PluginPolicy struct {
 	ID            pgtype.UUID
 	PublicKey     string
 	PluginID      string
 	PluginVersion string
-	PolicyVersion string
+	PolicyVersion int32
 	Signature     string
 	Active        bool
 	Recipe        string
 	Deleted       bool
+	CreatedAt     pgtype.Timestamptz
+	UpdatedAt     pgtype.Timestamptz
+	DeletedAt     pgtype.Timestamptz
+}
+
+type PluginPolicyRevision struct {
+	PolicyID      pgtype.UUID
+	Revision      int32
+	PluginVersion string
+	PolicyVersion int32
+	Signature     string
+	Active        bool
+	Recipe        string
+	CreatedAt     pgtype.Timestamptz
+}
+
+type PolicyExecution struct {
+	ProposalID pgtype.UUID
+	PolicyID   pgtype.UUID
+	CreatedAt  pgtype.Timestamp
+	TxIndex    int32
+}
+
+type Proposal struct {
+	ID                pgtype.UUID
+	PolicyID          pgtype.UUID
+	Chain             string
+	TxHex             string
+	Broadcast         bool
+	Status            ProposalStatus
+	Signatures        []byte
+	SignedTxHex       pgtype.Text
+	TxHash            pgtype.Text
+	BroadcastTxHash   pgtype.Text
+	BroadcastError    pgtype.Text
+	Error             pgtype.Text
+	CreatedAt         pgtype.Timestamp
+	UpdatedAt         pgtype.Timestamp
+	PublicKey         string
+	IdempotencyKey    string
+	ApprovalExpiresAt pgtype.Timestamp
+	Batch             []byte
+	CallbackUrl       pgtype.Text
+	RequestDigest     string
+}
+
+type SpendLedger struct {
+	ProposalID pgtype.UUID
+	PolicyID   pgtype.UUID
+	Chain      string
+	Token      string
+	Amount     pgtype.Numeric
+	CreatedAt  pgtype.Timestamp
 }
 
 type SystemEvent struct {
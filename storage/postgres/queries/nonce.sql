@@ -0,0 +1,39 @@
+-- name: LockNonces :exec
+SELECT pg_advisory_xact_lock(hashtextextended(sqlc.arg('chain')::text || ':' || sqlc.arg('address')::text, 0));
+
+-- name: ListHeldNonces :many
+SELECT l.chain, l.address, l.nonce, l.reservation_id, l.proposal_id, l.expires_at, l.created_at, l.policy_id
+FROM nonce_ledger l
+LEFT JOIN proposals p ON p.id = l.proposal_id
+WHERE l.chain = sqlc.arg('chain')
+  AND l.address = sqlc.arg('address')
+  AND l.nonce >= sqlc.arg('from_nonce')
+  AND (
+    p.status NOT IN ('failed', 'signed')
+    OR (p.status = 'signed' AND p.updated_at > CURRENT_TIMESTAMP - make_interval(secs => sqlc.arg('signed_hold_seconds')::double precision))
+    OR (l.proposal_id IS NULL AND l.expires_at > sqlc.arg('now'))
+  )
+ORDER BY l.nonce ASC;
+
+-- name: UpsertNonceEntry :exec
+INSERT INTO nonce_ledger (
+    chain, address, nonce, reservation_id, proposal_id, expires_at, policy_id
+) VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (chain, address, nonce) DO UPDATE
+SET reservation_id = EXCLUDED.reservation_id,
+    proposal_id = EXCLUDED.proposal_id,
+    expires_at = EXCLUDED.expires_at,
+    policy_id = EXCLUDED.policy_id,
+    created_at = CURRENT_TIMESTAMP;
+
+-- name: DeleteNoncesBelow :exec
+DELETE FROM nonce_ledger
+WHERE chain = $1
+  AND address = $2
+  AND nonce < $3;
+
+-- name: DeleteNonceReservation :execrows
+DELETE FROM nonce_ledger
+WHERE reservation_id = $1
+  AND policy_id = $2
+  AND proposal_id IS NULL;
@@ -1,14 +1,28 @@
 -- name: GetPluginPolicy :one
 SELECT id, public_key, plugin_id, plugin_version, policy_version, signature, active, recipe
 FROM plugin_policies 
-WHERE id = $1;
+WHERE id = $1
+  AND deleted = false;
 
--- name: GetAllPluginPolicies :many
-SELECT id, public_key, plugin_id, plugin_version, policy_version, signature, active, recipe
+-- name: GetDeletedPluginPolicy :one
+SELECT id, public_key, plugin_id, plugin_version, policy_version, signature, active, recipe, deleted, created_at, deleted_at
+FROM plugin_policies
+WHERE id = $1
+  AND deleted = true;
+
+-- name: ListPluginPolicies :many
+SELECT id, public_key, plugin_id, plugin_version, policy_version, signature, active, recipe, deleted, created_at, deleted_at
 FROM plugin_policies
-WHERE public_key = $1
-  AND plugin_id = $2
-  AND ($3::boolean = false OR active = true);
+WHERE (sqlc.narg('plugin_id')::text IS NULL OR plugin_id::text = sqlc.narg('plugin_id')::text)
+  AND (sqlc.narg('public_key')::text IS NULL OR public_key = sqlc.narg('public_key')::text)
+  AND (sqlc.narg('active')::boolean IS NULL OR active = sqlc.narg('active')::boolean)
+  AND (sqlc.narg('deleted')::boolean IS NULL OR deleted = sqlc.narg('deleted')::boolean)
+  AND (sqlc.narg('created_from')::timestamptz IS NULL OR created_at >= sqlc.narg('created_from')::timestamptz)
+  AND (sqlc.narg('created_to')::timestamptz IS NULL OR created_at < sqlc.narg('created_to')::timestamptz)
+  AND (sqlc.narg('cursor_created_at')::timestamptz IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamptz, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('row_limit')::int;
 
 -- name: InsertPluginPolicy :one
 INSERT INTO plugin_policies (
@@ -24,9 +38,29 @@ SET plugin_version = $2,
     active = $5,
     recipe = $6
 WHERE id = $1
+  AND deleted = false
+  AND policy_version = $3 - 1
 RETURNING id, public_key, plugin_id, plugin_version, policy_version, signature, active, recipe;
 
--- name: SoftDeletePluginPolicy :exec
+-- name: SoftDeletePluginPolicy :execrows
 UPDATE plugin_policies
-SET deleted = true
-WHERE id = $1;
\ No newline at end of file
+SET deleted = true,
+    deleted_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1
+  AND deleted = false;
+
+-- name: RestorePluginPolicy :execrows
+UPDATE plugin_policies
+SET deleted = false,
+    deleted_at = NULL,
+    updated_at = NOW()
+WHERE id = $1
+  AND deleted = true
+  AND deleted_at >= sqlc.arg('deleted_after')::timestamptz;
+
+-- name: PurgeDeletedPluginPolicies :many
+DELETE FROM plugin_policies
+WHERE deleted = true
+  AND deleted_at < sqlc.arg('deleted_before')::timestamptz
+RETURNING id, public_key, plugin_id, plugin_version, policy_version, signature, active, recipe, deleted, created_at, deleted_at;
@@ -11,64 +11,51 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-const getAllPluginPolicies = `-- name: GetAllPluginPolicies :many
-SELECT id, public_key, plugin_id, plugin_version, policy_version, signature, active, recipe
+const getDeletedPluginPolicy = `-- name: GetDeletedPluginPolicy :one
+SELECT id, public_key, plugin_id, plugin_version, policy_version, signature, active, recipe, deleted, created_at, deleted_at
 FROM plugin_policies
-WHERE public_key = $1
-  AND plugin_id = $2
-  AND ($3::boolean = false OR active = true)
+WHERE id = $1
+  AND deleted = true
 `
 
-type GetAllPluginPoliciesParams struct {
-	PublicKey string
-	PluginID  string
-	Column3   bool
-}
-
-type GetAllPluginPoliciesRow struct {
+type GetDeletedPluginPolicyRow struct {
 	ID            pgtype.UUID
 	PublicKey     string
 	PluginID      string
 	PluginVersion string
-	PolicyVersion string
+	PolicyVersion int32
 	Signature     string
 	Active        bool
 	Recipe        string
+	Deleted       bool
+	CreatedAt     pgtype.Timestamptz
+	DeletedAt     pgtype.Timestamptz
 }
 
-func (q *Queries) GetAllPluginPolicies(ctx context.Context, arg GetAllPluginPoliciesParams) ([]GetAllPluginPoliciesRow, error) {
-	rows, err := q.db.Query(ctx, getAllPluginPolicies, arg.PublicKey, arg.PluginID, arg.Column3)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetAllPluginPoliciesRow
-	for rows.Next() {
-		var i GetAllPluginPoliciesRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.PublicKey,
-			&i.PluginID,
-			&i.PluginVersion,
-			&i.PolicyVersion,
-			&i.Signature,
-			&i.Active,
-			&i.Recipe,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+func (q *Queries) GetDeletedPluginPolicy(ctx context.Context, id pgtype.UUID) (GetDeletedPluginPolicyRow, error) {
+	row := q.db.QueryRow(ctx, getDeletedPluginPolicy, id)
+	var i GetDeletedPluginPolicyRow
+	err := row.Scan(
+		&i.ID,
+		&i.PublicKey,
+		&i.PluginID,
+		&i.PluginVersion,
+		&i.PolicyVersion,
+		&i.Signature,
+		&i.Active,
+		&i.Recipe,
+		&i.Deleted,
+		&i.CreatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
 }
 
 const getPluginPolicy = `-- name: GetPluginPolicy :one
 SELECT id, public_key, plugin_id, plugin_version, policy_version, signature, active, recipe
 FROM plugin_policies 
 WHERE id = $1
+  AND deleted = false
 `
 
 type GetPluginPolicyRow struct {
@@ -76,7 +63,7 @@ type GetPluginPolicyRow struct {
 	PublicKey     string
 	PluginID      string
 	PluginVersion string
-	PolicyVersion string
+	PolicyVersion int32
 	Signature     string
 	Active        bool
 	Recipe        string
@@ -110,7 +97,7 @@ type InsertPluginPolicyParams struct {
 	PublicKey     string
 	PluginID      string
 	PluginVersion string
-	PolicyVersion string
+	PolicyVersion int32
 	Signature     string
 	Active        bool
 	Recipe        string
@@ -121,7 +108,7 @@ type InsertPluginPolicyRow struct {
 	PublicKey     string
 	PluginID      string
 	PluginVersion string
-	PolicyVersion string
+	PolicyVersion int32
 	Signature     string
 	Active        bool
 	Recipe        string
@@ -152,15 +139,180 @@ func (q *Queries) InsertPluginPolicy(ctx context.Context, arg InsertPluginPolicy
 	return i, err
 }
 
-const softDeletePluginPolicy = `-- name: SoftDeletePluginPolicy :exec
+const listPluginPolicies = `-- name: ListPluginPolicies :many
+SELECT id, public_key, plugin_id, plugin_version, policy_version, signature, active, recipe, deleted, created_at, deleted_at
+FROM plugin_policies
+WHERE ($1::text IS NULL OR plugin_id::text = $1::text)
+  AND ($2::text IS NULL OR public_key = $2::text)
+  AND ($3::boolean IS NULL OR active = $3::boolean)
+  AND ($4::boolean IS NULL OR deleted = $4::boolean)
+  AND ($5::timestamptz IS NULL OR created_at >= $5::timestamptz)
+  AND ($6::timestamptz IS NULL OR created_at < $6::timestamptz)
+  AND ($7::timestamptz IS NULL
+    OR (created_at, id) < ($7::timestamptz, $8::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $9::int
+`
+
+type ListPluginPoliciesParams struct {
+	PluginID        pgtype.Text
+	PublicKey       pgtype.Text
+	Active          pgtype.Bool
+	Deleted         pgtype.Bool
+	CreatedFrom     pgtype.Timestamptz
+	CreatedTo       pgtype.Timestamptz
+	CursorCreatedAt pgtype.Timestamptz
+	CursorID        pgtype.UUID
+	RowLimit        int32
+}
+
+type ListPluginPoliciesRow struct {
+	ID            pgtype.UUID
+	PublicKey     string
+	PluginID      string
+	PluginVersion string
+	PolicyVersion int32
+	Signature     string
+	Active        bool
+	Recipe        string
+	Deleted       bool
+	CreatedAt     pgtype.Timestamptz
+	DeletedAt     pgtype.Timestamptz
+}
+
+func (q *Queries) ListPluginPolicies(ctx context.Context, arg ListPluginPoliciesParams) ([]ListPluginPoliciesRow, error) {
+	rows, err := q.db.Query(ctx, listPluginPolicies,
+		arg.PluginID,
+		arg.PublicKey,
+		arg.Active,
+		arg.Deleted,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPluginPoliciesRow
+	for rows.Next() {
+		var i ListPluginPoliciesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.PublicKey,
+			&i.PluginID,
+			&i.PluginVersion,
+			&i.PolicyVersion,
+			&i.Signature,
+			&i.Active,
+			&i.Recipe,
+			&i.Deleted,
+			&i.CreatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeDeletedPluginPolicies = `-- name: PurgeDeletedPluginPolicies :many
+DELETE FROM plugin_policies
+WHERE deleted = true
+  AND deleted_at < $1::timestamptz
+RETURNING id, public_key, plugin_id, plugin_version, policy_version, signature, active, recipe, deleted, created_at, deleted_at
+`
+
+type PurgeDeletedPluginPoliciesRow struct {
+	ID            pgtype.UUID
+	PublicKey     string
+	PluginID      string
+	PluginVersion string
+	PolicyVersion int32
+	Signature     string
+	Active        bool
+	Recipe        string
+	Deleted       bool
+	CreatedAt     pgtype.Timestamptz
+	DeletedAt     pgtype.Timestamptz
+}
+
+func (q *Queries) PurgeDeletedPluginPolicies(ctx context.Context, deletedBefore pgtype.Timestamptz) ([]PurgeDeletedPluginPoliciesRow, error) {
+	rows, err := q.db.Query(ctx, purgeDeletedPluginPolicies, deletedBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PurgeDeletedPluginPoliciesRow
+	for rows.Next() {
+		var i PurgeDeletedPluginPoliciesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.PublicKey,
+			&i.PluginID,
+			&i.PluginVersion,
+			&i.PolicyVersion,
+			&i.Signature,
+			&i.Active,
+			&i.Recipe,
+			&i.Deleted,
+			&i.CreatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const restorePluginPolicy = `-- name: RestorePluginPolicy :execrows
+UPDATE plugin_policies
+SET deleted = false,
+    deleted_at = NULL,
+    updated_at = NOW()
+WHERE id = $1
+  AND deleted = true
+  AND deleted_at >= $2::timestamptz
+`
+
+type RestorePluginPolicyParams struct {
+	ID           pgtype.UUID
+	DeletedAfter pgtype.Timestamptz
+}
+
+func (q *Queries) RestorePluginPolicy(ctx context.Context, arg RestorePluginPolicyParams) (int64, error) {
+	result, err := q.db.Exec(ctx, restorePluginPolicy, arg.ID, arg.DeletedAfter)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const softDeletePluginPolicy = `-- name: SoftDeletePluginPolicy :execrows
 UPDATE plugin_policies
-SET deleted = true
+SET deleted = true,
+    deleted_at = NOW(),
+    updated_at = NOW()
 WHERE id = $1
+  AND deleted = false
 `
 
-func (q *Queries) SoftDeletePluginPolicy(ctx context.Context, id pgtype.UUID) error {
-	_, err := q.db.Exec(ctx, softDeletePluginPolicy, id)
-	return err
+func (q *Queries) SoftDeletePluginPolicy(ctx context.Context, id pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, softDeletePluginPolicy, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
 const updatePluginPolicy = `-- name: UpdatePluginPolicy :one
@@ -171,13 +323,15 @@ SET plugin_version = $2,
     active = $5,
     recipe = $6
 WHERE id = $1
+  AND deleted = false
+  AND policy_version = $3 - 1
 RETURNING id, public_key, plugin_id, plugin_version, policy_version, signature, active, recipe
 `
 
 type UpdatePluginPolicyParams struct {
 	ID            pgtype.UUID
 	PluginVersion string
-	PolicyVersion string
+	PolicyVersion int32
 	Signature     string
 	Active        bool
 	Recipe        string
@@ -188,7 +342,7 @@ type UpdatePluginPolicyRow struct {
 	PublicKey     string
 	PluginID      string
 	PluginVersion string
-	PolicyVersion string
+	PolicyVersion int32
 	Signature     string
 	Active        bool
 	Recipe        string
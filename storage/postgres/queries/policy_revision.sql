@@ -0,0 +1,22 @@
+-- name: InsertPluginPolicyRevision :one
+INSERT INTO plugin_policy_revisions (
+    policy_id, revision, plugin_version, policy_version, signature, active, recipe
+)
+SELECT p.id,
+       COALESCE((SELECT MAX(r.revision) FROM plugin_policy_revisions r WHERE r.policy_id = p.id), 0) + 1,
+       p.plugin_version, p.policy_version, p.signature, p.active, p.recipe
+FROM plugin_policies p
+WHERE p.id = $1
+RETURNING policy_id, revision, plugin_version, policy_version, signature, active, recipe, created_at;
+
+-- name: ListPluginPolicyRevisions :many
+SELECT policy_id, revision, plugin_version, policy_version, signature, active, recipe, created_at
+FROM plugin_policy_revisions
+WHERE policy_id = $1
+ORDER BY revision ASC;
+
+-- name: GetPluginPolicyRevision :one
+SELECT policy_id, revision, plugin_version, policy_version, signature, active, recipe, created_at
+FROM plugin_policy_revisions
+WHERE policy_id = $1
+  AND revision = $2;
@@ -0,0 +1,74 @@
+-- name: InsertProposal :one
+INSERT INTO proposals (
+    id, policy_id, public_key, chain, tx_hex, broadcast, status, idempotency_key, approval_expires_at, batch, callback_url, request_digest
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+ON CONFLICT (idempotency_key) WHERE status <> 'failed' DO NOTHING
+RETURNING *;
+
+-- name: GetProposal :one
+SELECT * FROM proposals
+WHERE id = $1;
+
+-- name: GetActiveProposalByIdempotencyKey :one
+SELECT * FROM proposals
+WHERE idempotency_key = $1
+  AND status <> 'failed';
+
+-- name: ListProposals :many
+SELECT * FROM proposals
+WHERE (sqlc.narg('policy_id')::uuid IS NULL OR policy_id = sqlc.narg('policy_id')::uuid)
+  AND (sqlc.narg('public_key')::text IS NULL OR public_key = sqlc.narg('public_key')::text)
+  AND (sqlc.narg('status')::proposal_status IS NULL OR status = sqlc.narg('status')::proposal_status)
+  AND (sqlc.narg('created_from')::timestamp IS NULL OR created_at >= sqlc.narg('created_from')::timestamp)
+  AND (sqlc.narg('created_to')::timestamp IS NULL OR created_at < sqlc.narg('created_to')::timestamp)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('row_limit')::int
+OFFSET sqlc.arg('row_offset')::int;
+
+-- name: UpdateProposalStatus :exec
+UPDATE proposals
+SET status = $2,
+    error = $3,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: UpdateProposalSigned :exec
+UPDATE proposals
+SET status = 'signed',
+    signatures = $2,
+    signed_tx_hex = $3,
+    tx_hash = $4,
+    broadcast_tx_hash = $5,
+    broadcast_error = $6,
+    batch = $7,
+    error = NULL,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: ResolvePendingApproval :one
+UPDATE proposals
+SET status = $2,
+    error = $3,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+  AND status = 'pending_approval'
+  AND approval_expires_at > $4
+RETURNING *;
+
+-- name: ExpirePendingApprovals :many
+UPDATE proposals
+SET status = 'failed',
+    error = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE status = 'pending_approval'
+  AND approval_expires_at <= $1
+RETURNING *;
+
+-- name: FailStaleSigningProposals :many
+UPDATE proposals
+SET status = 'failed',
+    error = $1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE status = 'signing'
+  AND updated_at < CURRENT_TIMESTAMP - make_interval(secs => sqlc.arg('stale_seconds')::double precision)
+RETURNING *;
@@ -0,0 +1,17 @@
+-- name: LockPolicyLedgers :exec
+SELECT pg_advisory_xact_lock(hashtextextended(sqlc.arg('policy_id')::text, 0));
+
+-- name: InsertSpendEntry :exec
+INSERT INTO spend_ledger (
+    proposal_id, policy_id, chain, token, amount
+) VALUES ($1, $2, $3, $4, $5);
+
+-- name: GetSpendUsage :one
+SELECT COALESCE(SUM(l.amount), 0)::text AS used
+FROM spend_ledger l
+JOIN proposals p ON p.id = l.proposal_id
+WHERE l.policy_id = sqlc.arg('policy_id')
+  AND l.chain = sqlc.arg('chain')
+  AND l.token = sqlc.arg('token')
+  AND p.status <> 'failed'
+  AND l.created_at > CURRENT_TIMESTAMP - make_interval(secs => sqlc.arg('window_seconds')::double precision);
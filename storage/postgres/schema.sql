@@ -1,15 +1,35 @@
-CREATE TYPE system_event_type AS ENUM ('vault_reshared', 'vault_deleted', 'policy_created', 'policy_deleted');
+CREATE TYPE system_event_type AS ENUM ('vault_reshared', 'vault_deleted', 'policy_created', 'policy_deleted', 'policy_restored', 'policy_purged', 'proposal_pending_approval', 'proposal_approved', 'proposal_rejected', 'proposal_approval_expired');
+
+CREATE TYPE proposal_status AS ENUM ('queued', 'signing', 'signed', 'failed', 'pending_approval');
 
 CREATE TABLE IF NOT EXISTS plugin_policies (
     id UUID PRIMARY KEY,
     public_key TEXT NOT NULL,
     plugin_id TEXT NOT NULL,
     plugin_version TEXT NOT NULL,
-    policy_version TEXT NOT NULL,
+    policy_version INTEGER NOT NULL,
     signature TEXT NOT NULL,
     active BOOLEAN NOT NULL DEFAULT true,
     recipe TEXT NOT NULL,
-    deleted BOOLEAN NOT NULL DEFAULT false
+    deleted BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_plugin_policies_created_at_id ON plugin_policies (created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_plugin_policies_deleted_at ON plugin_policies (deleted_at) WHERE deleted = true;
+
+CREATE TABLE IF NOT EXISTS plugin_policy_revisions (
+    policy_id UUID NOT NULL,
+    revision INTEGER NOT NULL,
+    plugin_version TEXT NOT NULL,
+    policy_version INTEGER NOT NULL,
+    signature TEXT NOT NULL,
+    active BOOLEAN NOT NULL,
+    recipe TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (policy_id, revision)
 );
 
 CREATE TABLE IF NOT EXISTS system_events (
@@ -24,4 +44,69 @@ CREATE TABLE IF NOT EXISTS system_events (
 CREATE INDEX IF NOT EXISTS idx_system_events_public_key ON system_events (public_key);
 CREATE INDEX IF NOT EXISTS idx_system_events_policy_id ON system_events (policy_id);
 CREATE INDEX IF NOT EXISTS idx_system_events_event_type ON system_events (event_type);
-CREATE INDEX IF NOT EXISTS idx_system_events_created_at ON system_events (created_at);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_system_events_created_at ON system_events (created_at);
+
+CREATE TABLE IF NOT EXISTS proposals (
+    id UUID PRIMARY KEY,
+    policy_id UUID NOT NULL,
+    chain TEXT NOT NULL,
+    tx_hex TEXT NOT NULL,
+    broadcast BOOLEAN NOT NULL DEFAULT false,
+    status proposal_status NOT NULL DEFAULT 'queued',
+    signatures JSONB,
+    signed_tx_hex TEXT,
+    tx_hash TEXT,
+    broadcast_tx_hash TEXT,
+    broadcast_error TEXT,
+    error TEXT,
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    public_key TEXT NOT NULL DEFAULT '',
+    idempotency_key TEXT NOT NULL,
+    approval_expires_at TIMESTAMP WITHOUT TIME ZONE,
+    batch JSONB,
+    callback_url TEXT,
+    request_digest TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_proposals_policy_id ON proposals (policy_id);
+CREATE INDEX IF NOT EXISTS idx_proposals_status ON proposals (status);
+CREATE INDEX IF NOT EXISTS idx_proposals_public_key ON proposals (public_key);
+CREATE INDEX IF NOT EXISTS idx_proposals_created_at ON proposals (created_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_proposals_idempotency_key ON proposals (idempotency_key) WHERE status <> 'failed';
+CREATE INDEX IF NOT EXISTS idx_proposals_approval_expires_at ON proposals (approval_expires_at) WHERE approval_expires_at IS NOT NULL;
+CREATE TABLE IF NOT EXISTS spend_ledger (
+    proposal_id UUID NOT NULL REFERENCES proposals (id) ON DELETE CASCADE,
+    policy_id UUID NOT NULL,
+    chain TEXT NOT NULL,
+    token TEXT NOT NULL,
+    amount NUMERIC(78, 0) NOT NULL,
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (proposal_id, token)
+);
+
+CREATE INDEX IF NOT EXISTS idx_spend_ledger_window ON spend_ledger (policy_id, chain, token, created_at);
+
+CREATE TABLE IF NOT EXISTS policy_executions (
+    proposal_id UUID NOT NULL REFERENCES proposals (id) ON DELETE CASCADE,
+    policy_id UUID NOT NULL,
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    tx_index INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (proposal_id, tx_index)
+);
+
+CREATE INDEX IF NOT EXISTS idx_policy_executions_window ON policy_executions (policy_id, created_at);
+
+CREATE TABLE IF NOT EXISTS nonce_ledger (
+    chain TEXT NOT NULL,
+    address TEXT NOT NULL,
+    nonce BIGINT NOT NULL,
+    reservation_id UUID,
+    proposal_id UUID REFERENCES proposals (id) ON DELETE CASCADE,
+    expires_at TIMESTAMP WITHOUT TIME ZONE,
+    created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    policy_id UUID,
+    PRIMARY KEY (chain, address, nonce)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_nonce_ledger_reservation_id ON nonce_ledger (reservation_id) WHERE reservation_id IS NOT NULL;
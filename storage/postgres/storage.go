@@ -2,8 +2,10 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,6 +14,7 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sirupsen/logrus"
 	vtypes "github.com/vultisig/verifier/types"
+	"github.com/vultisig/vultisig-go/common"
 
 	"github.com/vultisig/pluginagent/storage/interfaces"
 	"github.com/vultisig/pluginagent/storage/postgres/queries"
@@ -92,7 +95,7 @@ func (s *Storage) GetPluginPolicy(ctx context.Context, id uuid.UUID) (*vtypes.Pl
 	row, err := s.queries.GetPluginPolicy(ctx, uuidToPgUUID(id))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("policy not found with ID: %s", id)
+			return nil, fmt.Errorf("%w with ID: %s", interfaces.ErrPolicyNotFound, id)
 		}
 		return nil, fmt.Errorf("failed to get policy: %w", err)
 	}
@@ -100,21 +103,31 @@ func (s *Storage) GetPluginPolicy(ctx context.Context, id uuid.UUID) (*vtypes.Pl
 	return toVTypesPluginPolicy(row)
 }
 
-func (s *Storage) GetAllPluginPolicies(ctx context.Context, publicKey string, pluginID vtypes.PluginID, onlyActive bool) ([]vtypes.PluginPolicy, error) {
-	params := queries.GetAllPluginPoliciesParams{
-		PublicKey: publicKey,
-		PluginID:  string(pluginID),
-		Column3:   onlyActive,
+func (s *Storage) ListPluginPolicies(ctx context.Context, filter types.PluginPolicyFilter) ([]types.PluginPolicyRecord, error) {
+	params := queries.ListPluginPoliciesParams{
+		PublicKey:   textToPgText(filter.PublicKey),
+		Active:      boolToPgBool(filter.Active),
+		Deleted:     boolToPgBool(filter.Deleted),
+		CreatedFrom: timeToPgTimestamptz(filter.CreatedFrom),
+		CreatedTo:   timeToPgTimestamptz(filter.CreatedTo),
+		RowLimit:    int32(filter.Limit),
+	}
+	if filter.PluginID != nil {
+		params.PluginID = pgtype.Text{String: string(*filter.PluginID), Valid: true}
+	}
+	if filter.After != nil {
+		params.CursorCreatedAt = pgtype.Timestamptz{Time: filter.After.CreatedAt, Valid: true}
+		params.CursorID = uuidToPgUUID(filter.After.ID)
 	}
 
-	rows, err := s.queries.GetAllPluginPolicies(ctx, params)
+	rows, err := s.queries.ListPluginPolicies(ctx, params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get policies: %w", err)
+		return nil, fmt.Errorf("failed to list policies: %w", err)
 	}
 
-	policies := make([]vtypes.PluginPolicy, 0, len(rows))
+	policies := make([]types.PluginPolicyRecord, 0, len(rows))
 	for _, row := range rows {
-		policy, err := toVTypesPluginPolicyFromGetAll(row)
+		policy, err := toTypesPluginPolicyRecord(row)
 		if err != nil {
 			return nil, err
 		}
@@ -125,12 +138,16 @@ func (s *Storage) GetAllPluginPolicies(ctx context.Context, publicKey string, pl
 }
 
 func (s *Storage) InsertPluginPolicy(ctx context.Context, policy vtypes.PluginPolicy) (*vtypes.PluginPolicy, error) {
+	policyVersion, err := policyVersionToInt32(policy.PolicyVersion)
+	if err != nil {
+		return nil, err
+	}
 	params := queries.InsertPluginPolicyParams{
 		ID:            uuidToPgUUID(policy.ID),
 		PublicKey:     policy.PublicKey,
 		PluginID:      string(policy.PluginID),
 		PluginVersion: policy.PluginVersion,
-		PolicyVersion: fmt.Sprintf("%d", policy.PolicyVersion),
+		PolicyVersion: policyVersion,
 		Signature:     policy.Signature,
 		Active:        policy.Active,
 		Recipe:        policy.Recipe,
@@ -145,10 +162,14 @@ func (s *Storage) InsertPluginPolicy(ctx context.Context, policy vtypes.PluginPo
 }
 
 func (s *Storage) UpdatePluginPolicy(ctx context.Context, policy vtypes.PluginPolicy) (*vtypes.PluginPolicy, error) {
+	policyVersion, err := policyVersionToInt32(policy.PolicyVersion)
+	if err != nil {
+		return nil, err
+	}
 	params := queries.UpdatePluginPolicyParams{
 		ID:            uuidToPgUUID(policy.ID),
 		PluginVersion: policy.PluginVersion,
-		PolicyVersion: fmt.Sprintf("%d", policy.PolicyVersion),
+		PolicyVersion: policyVersion,
 		Signature:     policy.Signature,
 		Active:        policy.Active,
 		Recipe:        policy.Recipe,
@@ -157,7 +178,16 @@ func (s *Storage) UpdatePluginPolicy(ctx context.Context, policy vtypes.PluginPo
 	row, err := s.queries.UpdatePluginPolicy(ctx, params)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("policy not found with ID: %s", policy.ID)
+			// Either there's no such policy or its version doesn't precede the submitted one
+			current, er := s.GetPluginPolicy(ctx, policy.ID)
+			if er != nil {
+				return nil, er
+			}
+			return nil, &interfaces.PolicyVersionConflictError{
+				PolicyID:         policy.ID,
+				CurrentVersion:   current.PolicyVersion,
+				SubmittedVersion: policy.PolicyVersion,
+			}
 		}
 		return nil, fmt.Errorf("failed to update policy: %w", err)
 	}
@@ -165,15 +195,108 @@ func (s *Storage) UpdatePluginPolicy(ctx context.Context, policy vtypes.PluginPo
 	return toVTypesPluginPolicyFromUpdate(row)
 }
 
+func (s *Storage) InsertPluginPolicyRevision(ctx context.Context, policyID uuid.UUID) (*types.PluginPolicyRevision, error) {
+	row, err := s.queries.InsertPluginPolicyRevision(ctx, uuidToPgUUID(policyID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w with ID: %s", interfaces.ErrPolicyNotFound, policyID)
+		}
+		return nil, fmt.Errorf("failed to insert policy revision: %w", err)
+	}
+
+	return toTypesPluginPolicyRevision(row)
+}
+
+func (s *Storage) ListPluginPolicyRevisions(ctx context.Context, policyID uuid.UUID) ([]types.PluginPolicyRevision, error) {
+	rows, err := s.queries.ListPluginPolicyRevisions(ctx, uuidToPgUUID(policyID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list policy revisions: %w", err)
+	}
+
+	revisions := make([]types.PluginPolicyRevision, 0, len(rows))
+	for _, row := range rows {
+		revision, err := toTypesPluginPolicyRevision(row)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, *revision)
+	}
+
+	return revisions, nil
+}
+
+func (s *Storage) GetPluginPolicyRevision(ctx context.Context, policyID uuid.UUID, revision int) (*types.PluginPolicyRevision, error) {
+	row, err := s.queries.GetPluginPolicyRevision(ctx, queries.GetPluginPolicyRevisionParams{
+		PolicyID: uuidToPgUUID(policyID),
+		Revision: int32(revision),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: policy %s has no revision %d", interfaces.ErrPolicyRevisionNotFound, policyID, revision)
+		}
+		return nil, fmt.Errorf("failed to get policy revision: %w", err)
+	}
+
+	return toTypesPluginPolicyRevision(row)
+}
+
 func (s *Storage) DeletePluginPolicy(ctx context.Context, id uuid.UUID) error {
-	err := s.queries.SoftDeletePluginPolicy(ctx, uuidToPgUUID(id))
+	deleted, err := s.queries.SoftDeletePluginPolicy(ctx, uuidToPgUUID(id))
 	if err != nil {
 		return fmt.Errorf("failed to delete policy: %w", err)
 	}
+	if deleted == 0 {
+		return fmt.Errorf("%w with ID: %s", interfaces.ErrPolicyNotFound, id)
+	}
 
 	return nil
 }
 
+func (s *Storage) GetDeletedPluginPolicy(ctx context.Context, id uuid.UUID) (*types.PluginPolicyRecord, error) {
+	row, err := s.queries.GetDeletedPluginPolicy(ctx, uuidToPgUUID(id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w with ID: %s", interfaces.ErrPolicyNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get deleted policy: %w", err)
+	}
+
+	return toTypesPluginPolicyRecord(queries.ListPluginPoliciesRow(row))
+}
+
+func (s *Storage) RestorePluginPolicy(ctx context.Context, id uuid.UUID, deletedAfter time.Time) error {
+	restored, err := s.queries.RestorePluginPolicy(ctx, queries.RestorePluginPolicyParams{
+		ID:           uuidToPgUUID(id),
+		DeletedAfter: pgtype.Timestamptz{Time: deletedAfter, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to restore policy: %w", err)
+	}
+	if restored == 0 {
+		return fmt.Errorf("%w with ID: %s", interfaces.ErrPolicyNotFound, id)
+	}
+
+	return nil
+}
+
+func (s *Storage) PurgeDeletedPluginPolicies(ctx context.Context, deletedBefore time.Time) ([]types.PluginPolicyRecord, error) {
+	rows, err := s.queries.PurgeDeletedPluginPolicies(ctx, pgtype.Timestamptz{Time: deletedBefore, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge deleted policies: %w", err)
+	}
+
+	policies := make([]types.PluginPolicyRecord, 0, len(rows))
+	for _, row := range rows {
+		policy, err := toTypesPluginPolicyRecord(queries.ListPluginPoliciesRow(row))
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, *policy)
+	}
+
+	return policies, nil
+}
+
 func (s *Storage) WithTx(ctx context.Context, fn func(interfaces.DatabaseStorage) error) error {
 	tx, err := s.pool.Begin(ctx)
 	if err != nil {
@@ -231,3 +354,357 @@ func (s *Storage) GetEventsAfterTimestamp(ctx context.Context, createdAt time.Ti
 
 	return events, nil
 }
+
+func (s *Storage) InsertProposal(ctx context.Context, proposal types.Proposal) (*types.Proposal, bool, error) {
+	batch, err := batchToJSON(proposal.Batch)
+	if err != nil {
+		return nil, false, err
+	}
+	params := queries.InsertProposalParams{
+		ID:                uuidToPgUUID(proposal.ID),
+		PolicyID:          uuidToPgUUID(proposal.PolicyID),
+		PublicKey:         proposal.PublicKey,
+		Chain:             proposal.Chain.String(),
+		TxHex:             proposal.TxHex,
+		Broadcast:         proposal.Broadcast,
+		Status:            queries.ProposalStatus(proposal.Status),
+		IdempotencyKey:    proposal.IdempotencyKey,
+		ApprovalExpiresAt: timeToPgTimestamp(proposal.ApprovalExpiresAt),
+		Batch:             batch,
+		CallbackUrl:       textToPgText(proposal.CallbackURL),
+		RequestDigest:     proposal.RequestDigest,
+	}
+
+	// The unique index on idempotency_key makes the insert the point of deduplication across
+	// replicas. A conflicting proposal can fail between the insert and the lookup, in which case
+	// the key is free again and the insert is retried once.
+	for attempt := 0; attempt < 2; attempt++ {
+		row, err := s.queries.InsertProposal(ctx, params)
+		if err == nil {
+			p, er := toTypesProposal(row)
+			return p, true, er
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, fmt.Errorf("failed to insert proposal: %w", err)
+		}
+
+		row, err = s.queries.GetActiveProposalByIdempotencyKey(ctx, proposal.IdempotencyKey)
+		if err == nil {
+			p, er := toTypesProposal(row)
+			return p, false, er
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, fmt.Errorf("failed to get proposal by idempotency key: %w", err)
+		}
+	}
+
+	return nil, false, fmt.Errorf("failed to insert proposal: idempotency key %s is contended", proposal.IdempotencyKey)
+}
+
+func (s *Storage) GetProposal(ctx context.Context, id uuid.UUID) (*types.Proposal, error) {
+	row, err := s.queries.GetProposal(ctx, uuidToPgUUID(id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w with ID: %s", interfaces.ErrProposalNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get proposal: %w", err)
+	}
+
+	return toTypesProposal(row)
+}
+
+func (s *Storage) ListProposals(ctx context.Context, filter types.ProposalFilter) ([]types.Proposal, error) {
+	params := queries.ListProposalsParams{
+		PublicKey:   textToPgText(filter.PublicKey),
+		CreatedFrom: timeToPgTimestamp(filter.CreatedFrom),
+		CreatedTo:   timeToPgTimestamp(filter.CreatedTo),
+		RowLimit:    int32(filter.Limit),
+		RowOffset:   int32(filter.Offset),
+	}
+	if filter.PolicyID != nil {
+		params.PolicyID = uuidToPgUUID(*filter.PolicyID)
+	}
+	if filter.Status != nil {
+		params.Status = queries.NullProposalStatus{
+			ProposalStatus: queries.ProposalStatus(*filter.Status),
+			Valid:          true,
+		}
+	}
+
+	rows, err := s.queries.ListProposals(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list proposals: %w", err)
+	}
+
+	proposals := make([]types.Proposal, 0, len(rows))
+	for _, row := range rows {
+		proposal, err := toTypesProposal(row)
+		if err != nil {
+			return nil, err
+		}
+		proposals = append(proposals, *proposal)
+	}
+
+	return proposals, nil
+}
+
+func (s *Storage) UpdateProposalStatus(ctx context.Context, id uuid.UUID, status types.ProposalStatus, errMsg *string) error {
+	err := s.queries.UpdateProposalStatus(ctx, queries.UpdateProposalStatusParams{
+		ID:     uuidToPgUUID(id),
+		Status: queries.ProposalStatus(status),
+		Error:  textToPgText(errMsg),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update proposal status: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Storage) ResolvePendingApproval(
+	ctx context.Context,
+	id uuid.UUID,
+	status types.ProposalStatus,
+	errMsg *string,
+	now time.Time,
+) (*types.Proposal, error) {
+	row, err := s.queries.ResolvePendingApproval(ctx, queries.ResolvePendingApprovalParams{
+		ID:                uuidToPgUUID(id),
+		Status:            queries.ProposalStatus(status),
+		Error:             textToPgText(errMsg),
+		ApprovalExpiresAt: timeToPgTimestamp(&now),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w with ID: %s", interfaces.ErrProposalNotPending, id)
+		}
+		return nil, fmt.Errorf("failed to resolve pending approval: %w", err)
+	}
+
+	return toTypesProposal(row)
+}
+
+func (s *Storage) ExpirePendingApprovals(ctx context.Context, now time.Time, errMsg string) ([]types.Proposal, error) {
+	rows, err := s.queries.ExpirePendingApprovals(ctx, queries.ExpirePendingApprovalsParams{
+		ApprovalExpiresAt: timeToPgTimestamp(&now),
+		Error:             textToPgText(&errMsg),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire pending approvals: %w", err)
+	}
+
+	proposals := make([]types.Proposal, 0, len(rows))
+	for _, row := range rows {
+		proposal, err := toTypesProposal(row)
+		if err != nil {
+			return nil, err
+		}
+		proposals = append(proposals, *proposal)
+	}
+
+	return proposals, nil
+}
+
+func (s *Storage) FailStaleSigningProposals(ctx context.Context, staleAfter time.Duration, errMsg string) ([]types.Proposal, error) {
+	rows, err := s.queries.FailStaleSigningProposals(ctx, queries.FailStaleSigningProposalsParams{
+		Error:        textToPgText(&errMsg),
+		StaleSeconds: staleAfter.Seconds(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fail stale signing proposals: %w", err)
+	}
+
+	proposals := make([]types.Proposal, 0, len(rows))
+	for _, row := range rows {
+		proposal, err := toTypesProposal(row)
+		if err != nil {
+			return nil, err
+		}
+		proposals = append(proposals, *proposal)
+	}
+
+	return proposals, nil
+}
+
+func (s *Storage) UpdateProposalSigned(ctx context.Context, proposal types.Proposal) error {
+	signatures, err := json.Marshal(proposal.Signatures)
+	if err != nil {
+		return fmt.Errorf("failed to marshal signatures: %w", err)
+	}
+
+	batch, err := batchToJSON(proposal.Batch)
+	if err != nil {
+		return err
+	}
+
+	err = s.queries.UpdateProposalSigned(ctx, queries.UpdateProposalSignedParams{
+		ID:              uuidToPgUUID(proposal.ID),
+		Signatures:      signatures,
+		SignedTxHex:     textToPgText(proposal.SignedTxHex),
+		TxHash:          textToPgText(proposal.TxHash),
+		BroadcastTxHash: textToPgText(proposal.BroadcastTxHash),
+		BroadcastError:  textToPgText(proposal.BroadcastError),
+		Batch:           batch,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update signed proposal: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Storage) LockPolicyLedgers(ctx context.Context, policyID uuid.UUID) error {
+	if err := s.queries.LockPolicyLedgers(ctx, policyID.String()); err != nil {
+		return fmt.Errorf("failed to lock policy ledgers: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) GetSpendUsage(
+	ctx context.Context,
+	policyID uuid.UUID,
+	chain common.Chain,
+	token string,
+	window time.Duration,
+) (*big.Int, error) {
+	used, err := s.queries.GetSpendUsage(ctx, queries.GetSpendUsageParams{
+		PolicyID:      uuidToPgUUID(policyID),
+		Chain:         chain.String(),
+		Token:         token,
+		WindowSeconds: window.Seconds(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get spend usage: %w", err)
+	}
+
+	amount, ok := new(big.Int).SetString(used, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid spend usage %q", used)
+	}
+	return amount, nil
+}
+
+func (s *Storage) InsertSpendEntry(ctx context.Context, entry types.SpendEntry) error {
+	err := s.queries.InsertSpendEntry(ctx, queries.InsertSpendEntryParams{
+		ProposalID: uuidToPgUUID(entry.ProposalID),
+		PolicyID:   uuidToPgUUID(entry.PolicyID),
+		Chain:      entry.Chain.String(),
+		Token:      entry.Token,
+		Amount:     pgtype.Numeric{Int: entry.Amount, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to insert spend entry: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) GetPolicyExecutionTimes(ctx context.Context, policyID uuid.UUID, window time.Duration) ([]time.Time, error) {
+	rows, err := s.queries.GetPolicyExecutionTimes(ctx, queries.GetPolicyExecutionTimesParams{
+		PolicyID:      uuidToPgUUID(policyID),
+		WindowSeconds: window.Seconds(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get policy executions: %w", err)
+	}
+
+	times := make([]time.Time, 0, len(rows))
+	for _, row := range rows {
+		times = append(times, row.Time)
+	}
+	return times, nil
+}
+
+func (s *Storage) InsertPolicyExecution(ctx context.Context, proposalID, policyID uuid.UUID, txIndex int) error {
+	err := s.queries.InsertPolicyExecution(ctx, queries.InsertPolicyExecutionParams{
+		ProposalID: uuidToPgUUID(proposalID),
+		PolicyID:   uuidToPgUUID(policyID),
+		TxIndex:    int32(txIndex),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to insert policy execution: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) LockNonces(ctx context.Context, chain common.Chain, address string) error {
+	err := s.queries.LockNonces(ctx, queries.LockNoncesParams{
+		Chain:   chain.String(),
+		Address: address,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to lock nonces: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) ListHeldNonces(
+	ctx context.Context,
+	chain common.Chain,
+	address string,
+	fromNonce uint64,
+	now time.Time,
+	signedHold time.Duration,
+) ([]types.NonceEntry, error) {
+	rows, err := s.queries.ListHeldNonces(ctx, queries.ListHeldNoncesParams{
+		Chain:             chain.String(),
+		Address:           address,
+		FromNonce:         int64(fromNonce),
+		SignedHoldSeconds: signedHold.Seconds(),
+		Now:               timeToPgTimestamp(&now),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list held nonces: %w", err)
+	}
+
+	entries := make([]types.NonceEntry, 0, len(rows))
+	for _, row := range rows {
+		entry, err := toTypesNonceEntry(row)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+	return entries, nil
+}
+
+func (s *Storage) UpsertNonceEntry(ctx context.Context, entry types.NonceEntry) error {
+	err := s.queries.UpsertNonceEntry(ctx, queries.UpsertNonceEntryParams{
+		Chain:         entry.Chain.String(),
+		Address:       entry.Address,
+		Nonce:         int64(entry.Nonce),
+		ReservationID: optionalUUIDToPgUUID(entry.ReservationID),
+		ProposalID:    optionalUUIDToPgUUID(entry.ProposalID),
+		ExpiresAt:     timeToPgTimestamp(entry.ExpiresAt),
+		PolicyID:      uuidToPgUUID(entry.PolicyID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upsert nonce entry: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) DeleteNoncesBelow(ctx context.Context, chain common.Chain, address string, nonce uint64) error {
+	err := s.queries.DeleteNoncesBelow(ctx, queries.DeleteNoncesBelowParams{
+		Chain:   chain.String(),
+		Address: address,
+		Nonce:   int64(nonce),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete confirmed nonces: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) DeleteNonceReservation(ctx context.Context, id uuid.UUID, policyID uuid.UUID) error {
+	deleted, err := s.queries.DeleteNonceReservation(ctx, queries.DeleteNonceReservationParams{
+		ReservationID: uuidToPgUUID(id),
+		PolicyID:      uuidToPgUUID(policyID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete nonce reservation: %w", err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("%w with ID: %s", interfaces.ErrNonceReservationNotFound, id)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vultisig/vultisig-go/common"
+)
+
+// NonceEntry holds a nonce of a vault address. A reservation holds it until ExpiresAt, a proposal
+// until the proposal fails. Entries below the account nonce of the chain are confirmed and pruned.
+// PolicyID is the policy the nonce was reserved or claimed through.
+type NonceEntry struct {
+	Chain         common.Chain
+	Address       string
+	Nonce         uint64
+	PolicyID      uuid.UUID
+	ReservationID *uuid.UUID
+	ProposalID    *uuid.UUID
+	ExpiresAt     *time.Time
+	CreatedAt     time.Time
+}
@@ -1,6 +1,9 @@
 package types
 
 import (
+	"time"
+
+	"github.com/google/uuid"
 	rtypes "github.com/vultisig/recipes/types"
 	"github.com/vultisig/verifier/types"
 )
@@ -21,3 +24,43 @@ func FromPluginPolicy(policy types.PluginPolicy) (PluginPolicyWithRecipe, error)
 		Recipe:       recipe,
 	}, nil
 }
+
+// PluginPolicyRecord is a stored policy along with the fields the verifier type doesn't carry.
+type PluginPolicyRecord struct {
+	types.PluginPolicy
+	Deleted   bool       `json:"deleted"`
+	CreatedAt time.Time  `json:"created_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// PluginPolicyRevision is a policy as it was approved by the vault owner. Every create and update
+// of the policy records a revision, numbered from 1.
+type PluginPolicyRevision struct {
+	PolicyID      uuid.UUID `json:"policy_id"`
+	Revision      int       `json:"revision"`
+	PluginVersion string    `json:"plugin_version"`
+	PolicyVersion int       `json:"policy_version"`
+	Signature     string    `json:"signature"`
+	Active        bool      `json:"active"`
+	Recipe        string    `json:"recipe"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// PluginPolicyCursor is the position after the last policy of a page, policies are listed newest
+// first.
+type PluginPolicyCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// PluginPolicyFilter narrows down policy listings. Nil fields are not filtered on.
+type PluginPolicyFilter struct {
+	PluginID    *types.PluginID
+	PublicKey   *string
+	Active      *bool
+	Deleted     *bool
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	After       *PluginPolicyCursor
+	Limit       int
+}
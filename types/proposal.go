@@ -0,0 +1,82 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vultisig/mobile-tss-lib/tss"
+	"github.com/vultisig/vultisig-go/common"
+)
+
+type ProposalStatus string
+
+const (
+	ProposalStatusQueued  ProposalStatus = "queued"
+	ProposalStatusSigning ProposalStatus = "signing"
+	ProposalStatusSigned  ProposalStatus = "signed"
+	ProposalStatusFailed  ProposalStatus = "failed"
+	// ProposalStatusPendingApproval proposals wait for the vault owner to approve them before
+	// they are queued for signing.
+	ProposalStatusPendingApproval ProposalStatus = "pending_approval"
+)
+
+type Proposal struct {
+	ID             uuid.UUID
+	PolicyID       uuid.UUID
+	PublicKey      string
+	Chain          common.Chain
+	TxHex          string
+	Broadcast      bool
+	Status         ProposalStatus
+	IdempotencyKey string
+	// RequestDigest identifies the request the proposal was created from, a request reusing
+	// the idempotency key must have the same digest
+	RequestDigest string
+	// ApprovalExpiresAt is set on proposals that need the approval of the vault owner
+	ApprovalExpiresAt *time.Time
+	// Batch holds the transactions of a batch proposal, TxHex and the signing outcome fields
+	// are only used by single transaction proposals.
+	Batch []ProposalTx
+	// CallbackURL is notified when signing completes or fails
+	CallbackURL     *string
+	Signatures      map[string]tss.KeysignResponse
+	SignedTxHex     *string
+	TxHash          *string
+	BroadcastTxHash *string
+	BroadcastError  *string
+	Error           *string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// ProposalTx is a transaction of a batch proposal along with the outcome of signing it.
+type ProposalTx struct {
+	TxHex           string  `json:"tx_hex"`
+	SignedTxHex     *string `json:"signed_tx_hex,omitempty"`
+	TxHash          *string `json:"tx_hash,omitempty"`
+	BroadcastTxHash *string `json:"broadcast_tx_hash,omitempty"`
+	BroadcastError  *string `json:"broadcast_error,omitempty"`
+}
+
+// TxHexes returns the transactions of the proposal in signing order.
+func (p Proposal) TxHexes() []string {
+	if len(p.Batch) == 0 {
+		return []string{p.TxHex}
+	}
+	txHexes := make([]string, 0, len(p.Batch))
+	for _, tx := range p.Batch {
+		txHexes = append(txHexes, tx.TxHex)
+	}
+	return txHexes
+}
+
+// ProposalFilter narrows down proposal listings. Nil fields are not filtered on.
+type ProposalFilter struct {
+	PolicyID    *uuid.UUID
+	PublicKey   *string
+	Status      *ProposalStatus
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Limit       int
+	Offset      int
+}
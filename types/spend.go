@@ -0,0 +1,18 @@
+package types
+
+import (
+	"math/big"
+
+	"github.com/google/uuid"
+	"github.com/vultisig/vultisig-go/common"
+)
+
+// SpendEntry is the amount of a token moved by a proposal, in the token base unit. Entries of
+// failed proposals don't count towards the policy spend limits.
+type SpendEntry struct {
+	ProposalID uuid.UUID
+	PolicyID   uuid.UUID
+	Chain      common.Chain
+	Token      string
+	Amount     *big.Int
+}
@@ -9,10 +9,16 @@ import (
 type SystemEventType string
 
 const (
-	SystemEventTypeVaultReshared       SystemEventType = "vault_reshared"
-	SystemEventTypeVaultDeleted        SystemEventType = "vault_deleted"
-	SystemEventTypePluginPolicyCreated SystemEventType = "policy_created"
-	SystemEventTypePluginPolicyDeleted SystemEventType = "policy_deleted"
+	SystemEventTypeVaultReshared           SystemEventType = "vault_reshared"
+	SystemEventTypeVaultDeleted            SystemEventType = "vault_deleted"
+	SystemEventTypePluginPolicyCreated     SystemEventType = "policy_created"
+	SystemEventTypePluginPolicyDeleted     SystemEventType = "policy_deleted"
+	SystemEventTypePluginPolicyRestored    SystemEventType = "policy_restored"
+	SystemEventTypePluginPolicyPurged      SystemEventType = "policy_purged"
+	SystemEventTypeProposalPendingApproval SystemEventType = "proposal_pending_approval"
+	SystemEventTypeProposalApproved        SystemEventType = "proposal_approved"
+	SystemEventTypeProposalRejected        SystemEventType = "proposal_rejected"
+	SystemEventTypeProposalApprovalExpired SystemEventType = "proposal_approval_expired"
 )
 
 type SystemEvent struct {